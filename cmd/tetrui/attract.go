@@ -0,0 +1,156 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	attractIdleDelay    = 20 * time.Second
+	attractFadeDuration = 900 * time.Millisecond
+	attractActionDelay  = 90 * time.Millisecond
+)
+
+type idleTickMsg struct{}
+type attractFadeTickMsg struct{}
+type attractTickMsg struct{}
+
+func idleTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return idleTickMsg{} })
+}
+
+func attractFadeTickCmd() tea.Cmd {
+	return tea.Tick(60*time.Millisecond, func(time.Time) tea.Msg { return attractFadeTickMsg{} })
+}
+
+func attractTickCmd() tea.Cmd {
+	return tea.Tick(attractActionDelay, func(time.Time) tea.Msg { return attractTickMsg{} })
+}
+
+func (m *Model) updateIdle() tea.Cmd {
+	if m.screen != screenMenu || !m.attractTil.IsZero() {
+		return idleTickCmd()
+	}
+	if time.Since(m.lastInputAt) < attractIdleDelay {
+		return idleTickCmd()
+	}
+	DebugLogf("attract mode: fade in")
+	m.attractFrom = time.Now()
+	m.attractTil = m.attractFrom.Add(attractFadeDuration)
+	return tea.Batch(idleTickCmd(), attractFadeTickCmd())
+}
+
+func (m *Model) updateAttractFade() tea.Cmd {
+	if m.screen != screenMenu || m.attractTil.IsZero() {
+		return nil
+	}
+	if time.Now().Before(m.attractTil) {
+		return attractFadeTickCmd()
+	}
+	m.attractFrom = time.Time{}
+	m.attractTil = time.Time{}
+	m.game = NewGame()
+	m.attractAimed = false
+	return tea.Batch(m.setScreen(screenAttract), attractTickCmd())
+}
+
+func (m *Model) updateAttract() tea.Cmd {
+	if m.screen != screenAttract {
+		return nil
+	}
+	if m.game.Over {
+		m.game = NewGame()
+		m.attractAimed = false
+		return attractTickCmd()
+	}
+	if !m.attractAimed {
+		target, ok := choosePlacement(&m.game, 1, 0, nil)
+		if !ok {
+			m.game.Over = true
+			return attractTickCmd()
+		}
+		m.attractGoal = target
+		m.attractAimed = true
+		m.attractPath = nil
+	}
+	if m.attractGoal.Hold {
+		m.game.Hold()
+		m.attractGoal.Hold = false
+		m.attractPath = nil
+		return attractTickCmd()
+	}
+	if m.attractPath == nil {
+		// Follow the same search that picked the target, so tucks and
+		// spins land where the bot meant them to.
+		path, ok := m.game.pathTo(m.attractGoal)
+		if !ok {
+			path = []pieceMove{}
+		}
+		m.attractPath = path
+	}
+	if !slices.ContainsFunc(m.attractPath, func(move pieceMove) bool { return move != moveDown }) {
+		m.game.HardDrop()
+		m.game.ResolveLineClear()
+		m.attractAimed = false
+		m.attractPath = nil
+		return attractTickCmd()
+	}
+	applyPieceMove(&m.game, m.attractPath[0])
+	m.attractPath = m.attractPath[1:]
+	return attractTickCmd()
+}
+
+func (m *Model) stopAttract() tea.Cmd {
+	m.lastInputAt = time.Now()
+	m.attractFrom = time.Time{}
+	m.attractTil = time.Time{}
+	m.attractAimed = false
+	if m.screen == screenAttract {
+		m.game = NewGame()
+		return m.setScreen(screenMenu)
+	}
+	return nil
+}
+
+func applyPieceMove(g *Game, move pieceMove) {
+	switch move {
+	case moveLeft:
+		g.Move(-1)
+	case moveRight:
+		g.Move(1)
+	case moveDown:
+		g.SoftDrop()
+	case moveRotateCW:
+		g.Rotate(1)
+	case moveRotateCCW:
+		g.Rotate(-1)
+	}
+}
+
+func viewAttract(m Model) string {
+	theme := resolveGameTheme(m)
+	board := renderBoard(m.game, theme, 1, m.config.Shadow, nil, time.Time{}, time.Time{}, nil, nil, time.Time{}, time.Time{}, nil)
+	info := renderInfo(m.game, theme, 1, "", 0, "DEMO", m.config.keyHelp())
+	content := lipgloss.JoinHorizontal(lipgloss.Top, board, info)
+	footer := helpStyle(theme).Render("Press any key")
+	return center(m.width, m.height, lipgloss.JoinVertical(lipgloss.Center, content, "", footer))
+}
+
+func fadeTheme(theme Theme, progress float64) Theme {
+	steps := []lipgloss.Color{"245", "241", "238", "235"}
+	if progress <= 0 {
+		return theme
+	}
+	index := int(progress * float64(len(steps)))
+	if index >= len(steps) {
+		index = len(steps) - 1
+	}
+	faded := theme
+	faded.BorderColor = steps[index]
+	faded.TextColor = steps[index]
+	faded.AccentColor = steps[index]
+	return faded
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAttractFadeEntersAttract(t *testing.T) {
+	m := Model{screen: screenMenu, attractTil: time.Now().Add(-time.Second)}
+	if cmd := m.updateAttractFade(); cmd == nil {
+		t.Fatal("no tick scheduled for the attract screen")
+	}
+	if m.screen != screenAttract || !m.attractTil.IsZero() {
+		t.Fatalf("screen = %v, fade until %v", m.screen, m.attractTil)
+	}
+	m.stopAttract()
+	if m.screen != screenMenu {
+		t.Fatalf("stopAttract left screen %v", m.screen)
+	}
+}
+
+func TestPathToFollowsSpin(t *testing.T) {
+	g := tSpinDoubleGame()
+	slot := Placement{X: 0, Y: boardHeight - 3, Rotation: 2, Spin: true}
+	path, ok := g.pathTo(slot)
+	if !ok {
+		t.Fatal("no path into the T-spin slot")
+	}
+	for _, move := range path {
+		applyPieceMove(&g, move)
+	}
+	if g.X != slot.X || g.Y != slot.Y || g.Rotation != slot.Rotation {
+		t.Fatalf("path ended at %d,%d r%d", g.X, g.Y, g.Rotation)
+	}
+	if result := g.HardDrop(); !result.TSpin || result.Cleared != 2 {
+		t.Fatalf("drop = %+v, want a T-spin double", result)
+	}
+}
+
+func TestAttractLandsOnTarget(t *testing.T) {
+	m := Model{screen: screenAttract, game: NewGameWithSeed(4)}
+	landed := 0
+	for range 1000 {
+		goal, planned := m.attractGoal, m.attractAimed && !m.attractGoal.Hold && m.attractPath != nil
+		dropping := planned && !slices.ContainsFunc(m.attractPath, func(move pieceMove) bool { return move != moveDown })
+		if dropping && (m.game.X != goal.X || m.game.GhostY() != goal.Y || m.game.Rotation != goal.Rotation) {
+			t.Fatalf("piece %d drops at %d,%d r%d, target %+v", landed, m.game.X, m.game.GhostY(), m.game.Rotation, goal)
+		}
+		if dropping {
+			landed++
+		}
+		m.updateAttract()
+	}
+	if landed < 10 {
+		t.Fatalf("only %d pieces landed", landed)
+	}
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	backendWebhook = "webhook"
+	backendREST    = "rest"
+	backendFile    = "file"
+)
+
+var scoreBackends = []string{backendWebhook, backendREST, backendFile}
+
+type ScoreBackend interface {
+	Fetch(query ScoreQuery) ([]ScoreEntry, error)
+	Submit(entry ScoreEntry) error
+	Health() error
+}
+
+func NewScoreBackend(config Config) (ScoreBackend, error) {
+	url := strings.TrimSpace(config.ScoreAPIURL)
+	key := strings.TrimSpace(config.ScoreAPIKey)
+	client := &http.Client{Timeout: 4 * time.Second}
+	switch config.ScoreBackend {
+	case backendWebhook, "":
+		if url == "" {
+			return nil, errors.New("missing score_api_url")
+		}
+		return &webhookBackend{baseURL: strings.TrimRight(url, "/"), apiKey: key, secret: config.ScoreSecret, client: client}, nil
+	case backendREST:
+		if url == "" {
+			return nil, errors.New("missing score_api_url")
+		}
+		fields, err := parseFieldMap(config.ScoreFields)
+		if err != nil {
+			return nil, err
+		}
+		return &restBackend{
+			url:        strings.TrimRight(url, "/"),
+			authHeader: config.ScoreAuth,
+			apiKey:     key,
+			listKey:    config.ScoreList,
+			fields:     fields,
+			client:     client,
+		}, nil
+	case backendFile:
+		if strings.TrimSpace(config.ScoreDir) == "" {
+			return nil, errors.New("missing score_dir")
+		}
+		return &fileBackend{dir: config.ScoreDir}, nil
+	default:
+		return nil, fmt.Errorf("unknown score_backend %q", config.ScoreBackend)
+	}
+}
+
+type webhookBackend struct {
+	baseURL string
+	apiKey  string
+	secret  string
+	client  *http.Client
+}
+
+func (b *webhookBackend) request(method string, query url.Values, body []byte) (*http.Response, error) {
+	headers := map[string]string{"X-Api-Key": b.apiKey}
+	if body != nil && b.secret != "" {
+		headers[signatureHeader] = signPayload(b.secret, body)
+	}
+	target, err := withQuery(b.baseURL, query)
+	if err != nil {
+		return nil, err
+	}
+	return sendJSON(b.client, method, target, headers, body)
+}
+
+// withQuery adds query to base, keeping any parameters base already has.
+func withQuery(base string, query url.Values) (string, error) {
+	if len(query) == 0 {
+		return base, nil
+	}
+	parsed, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	values := parsed.Query()
+	for key := range query {
+		values.Set(key, query.Get(key))
+	}
+	parsed.RawQuery = values.Encode()
+	return parsed.String(), nil
+}
+
+// Fetch asks the server to filter and page. Servers that ignore the query
+// (no X-Total-Count header) get the same result by filtering locally.
+func (b *webhookBackend) Fetch(query ScoreQuery) ([]ScoreEntry, error) {
+	now := time.Now()
+	resp, err := b.request(http.MethodGet, query.Values(now), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var payload []apiScore
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	scores := make([]ScoreEntry, 0, len(payload))
+	for _, entry := range payload {
+		scores = append(scores, entry.ToScoreEntry())
+	}
+	if resp.Header.Get("X-Total-Count") == "" {
+		sortScores(scores)
+		return filterScores(scores, query, now), nil
+	}
+	return scores, nil
+}
+
+func (b *webhookBackend) Submit(entry ScoreEntry) error {
+	payload, err := json.Marshal(newUploadScore(entry))
+	if err != nil {
+		return err
+	}
+	resp, err := b.request(http.MethodPost, nil, payload)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (b *webhookBackend) Health() error {
+	resp, err := b.request(http.MethodGet, url.Values{"limit": {"1"}}, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+var restFieldNames = []string{
+	"id", "name", "score", "lines", "level", "when", "played_at", "mode",
+	"rule_set", "duration_ms", "pieces", "max_combo", "seed", "version", "platform", "board",
+}
+
+func parseFieldMap(text string) (map[string]string, error) {
+	fields := map[string]string{"when": "createdAt"}
+	for _, name := range restFieldNames {
+		if _, ok := fields[name]; !ok {
+			fields[name] = name
+		}
+	}
+	for _, pair := range strings.Split(text, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		ours, theirs, ok := strings.Cut(pair, "=")
+		ours = strings.TrimSpace(ours)
+		if _, known := fields[ours]; !ok || !known || strings.TrimSpace(theirs) == "" {
+			return nil, fmt.Errorf("score_fields: bad mapping %q (use field=json_key, fields: %s)", pair, strings.Join(restFieldNames, ", "))
+		}
+		fields[ours] = strings.TrimSpace(theirs)
+	}
+	return fields, nil
+}
+
+type restBackend struct {
+	url        string
+	authHeader string
+	apiKey     string
+	listKey    string
+	fields     map[string]string
+	client     *http.Client
+}
+
+func (b *restBackend) do(method string, query url.Values, body []byte) (*http.Response, error) {
+	header := b.authHeader
+	if header == "" {
+		header = "X-Api-Key"
+	}
+	target, err := withQuery(b.url, query)
+	if err != nil {
+		return nil, err
+	}
+	return sendJSON(b.client, method, target, map[string]string{header: b.apiKey}, body)
+}
+
+func sendJSON(client *http.Client, method, url string, headers map[string]string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		if value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, errUnexpectedStatus(resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// Fetch sends the same query parameters as the webhook backend and filters
+// locally when the server does not answer with X-Total-Count.
+func (b *restBackend) Fetch(query ScoreQuery) ([]ScoreEntry, error) {
+	now := time.Now()
+	resp, err := b.do(http.MethodGet, query.Values(now), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var items []map[string]any
+	if b.listKey == "" {
+		err = decodeNumbers(resp.Body, &items)
+	} else {
+		var wrapper map[string]json.RawMessage
+		if err = json.NewDecoder(resp.Body).Decode(&wrapper); err == nil {
+			err = decodeNumbers(bytes.NewReader(wrapper[b.listKey]), &items)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]ScoreEntry, 0, len(items))
+	for _, item := range items {
+		when := jsonString(item[b.fields["when"]])
+		if when == "" {
+			when = jsonString(item[b.fields["played_at"]])
+		}
+		scores = append(scores, ScoreEntry{
+			ID:         jsonString(item[b.fields["id"]]),
+			Name:       jsonString(item[b.fields["name"]]),
+			Score:      jsonInt(item[b.fields["score"]]),
+			Lines:      jsonInt(item[b.fields["lines"]]),
+			Level:      jsonInt(item[b.fields["level"]]),
+			When:       when,
+			Mode:       jsonString(item[b.fields["mode"]]),
+			RuleSet:    jsonString(item[b.fields["rule_set"]]),
+			DurationMs: jsonInt64(item[b.fields["duration_ms"]]),
+			Pieces:     jsonInt(item[b.fields["pieces"]]),
+			MaxCombo:   jsonInt(item[b.fields["max_combo"]]),
+			Seed:       jsonInt64(item[b.fields["seed"]]),
+			Version:    jsonString(item[b.fields["version"]]),
+			Platform:   jsonString(item[b.fields["platform"]]),
+			Board:      jsonString(item[b.fields["board"]]),
+		})
+	}
+	if resp.Header.Get("X-Total-Count") == "" {
+		sortScores(scores)
+		return filterScores(scores, query, now), nil
+	}
+	return scores, nil
+}
+
+func (b *restBackend) Submit(entry ScoreEntry) error {
+	upload := newUploadScore(entry)
+	body := map[string]any{
+		b.fields["name"]:  entry.Name,
+		b.fields["score"]: entry.Score,
+		b.fields["lines"]: entry.Lines,
+		b.fields["level"]: entry.Level,
+	}
+	optional := map[string]any{
+		"id":          entry.ID,
+		"when":        upload.PlayedAt,
+		"played_at":   upload.PlayedAt,
+		"mode":        entry.Mode,
+		"rule_set":    entry.RuleSet,
+		"duration_ms": entry.DurationMs,
+		"pieces":      entry.Pieces,
+		"max_combo":   entry.MaxCombo,
+		"seed":        entry.Seed,
+		"version":     entry.Version,
+		"platform":    entry.Platform,
+		"board":       entry.Board,
+	}
+	for field, value := range optional {
+		if value != "" && value != 0 && value != int64(0) {
+			body[b.fields[field]] = value
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := b.do(http.MethodPost, nil, payload)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (b *restBackend) Health() error {
+	resp, err := b.do(http.MethodGet, url.Values{"limit": {"1"}}, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// decodeNumbers keeps numbers as json.Number so seeds survive intact.
+func decodeNumbers(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func jsonInt(value any) int {
+	return int(jsonInt64(value))
+}
+
+func jsonInt64(value any) int64 {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return int64(f)
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
+
+type fileBackend struct {
+	dir string
+}
+
+func (b *fileBackend) Fetch(query ScoreQuery) ([]ScoreEntry, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	scores := []ScoreEntry{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(b.dir, entry.Name()))
+		if err != nil {
+			DebugLogf("file backend read error: %v", err)
+			continue
+		}
+		var score ScoreEntry
+		if err := json.Unmarshal(data, &score); err != nil {
+			DebugLogf("file backend parse error %s: %v", entry.Name(), err)
+			continue
+		}
+		scores = append(scores, score)
+	}
+	sortScores(scores)
+	return filterScores(scores, query, time.Now()), nil
+}
+
+func (b *fileBackend) Submit(entry ScoreEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, entry.Name)
+	host, _ := os.Hostname()
+	file := fmt.Sprintf("%d-%s-%s.json", time.Now().UnixNano(), name, host)
+	if validScoreID(entry.ID) {
+		file = entry.ID + ".json"
+	}
+	return replaceFile(filepath.Join(b.dir, file), data)
+}
+
+func (b *fileBackend) Health() error {
+	info, err := os.Stat(b.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", b.dir)
+	}
+	probe, err := os.CreateTemp(b.dir, ".tetrui-health-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
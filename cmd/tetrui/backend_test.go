@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryBackend is an in-process ScoreBackend for tests.
+type memoryBackend struct {
+	mu     sync.Mutex
+	scores []ScoreEntry
+	err    error
+}
+
+func (b *memoryBackend) Fetch(query ScoreQuery) ([]ScoreEntry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return nil, b.err
+	}
+	return filterScores(b.scores, query, time.Now()), nil
+}
+
+func (b *memoryBackend) Submit(entry ScoreEntry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	if newScoreIndex(b.scores).has(entry) {
+		return nil
+	}
+	b.scores = insertScore(b.scores, entry)
+	return nil
+}
+
+func (b *memoryBackend) Health() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+func (b *memoryBackend) SetError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+func newRESTStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	items := []map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer k" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			var item map[string]any
+			if err := decodeNumbers(r.Body, &item); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			items = append(items, item)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestScoreBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend func(t *testing.T) ScoreBackend
+	}{
+		{"webhook", func(t *testing.T) ScoreBackend {
+			server := newTestScoreServer(t, "k")
+			stub := httptest.NewServer(server.Handler())
+			t.Cleanup(stub.Close)
+			backend, err := NewScoreBackend(Config{ScoreAPIURL: stub.URL, ScoreAPIKey: "k"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return backend
+		}},
+		{"rest", func(t *testing.T) ScoreBackend {
+			stub := newRESTStub(t)
+			backend, err := NewScoreBackend(Config{
+				ScoreBackend: backendREST,
+				ScoreAPIURL:  stub.URL,
+				ScoreAuth:    "Authorization",
+				ScoreAPIKey:  "Bearer k",
+				ScoreList:    "items",
+				ScoreFields:  "name=player,score=points",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return backend
+		}},
+		{"file", func(t *testing.T) ScoreBackend {
+			backend, err := NewScoreBackend(Config{ScoreBackend: backendFile, ScoreDir: t.TempDir()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return backend
+		}},
+		{"memory", func(t *testing.T) ScoreBackend {
+			return &memoryBackend{}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := test.backend(t)
+			if err := backend.Health(); err != nil {
+				t.Fatalf("health: %v", err)
+			}
+			for _, entry := range []ScoreEntry{
+				{ID: newScoreID(), Name: "ann", Score: 500, Lines: 4, Level: 0, Mode: gameModeMarathon},
+				{ID: newScoreID(), Name: "bob", Score: 900, Lines: 8, Level: 0, Mode: gameModeBattle},
+			} {
+				if err := backend.Submit(entry); err != nil {
+					t.Fatalf("submit %s: %v", entry.Name, err)
+				}
+			}
+			scores, err := backend.Fetch(ScoreQuery{})
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if len(scores) != 2 || scores[0].Name != "bob" || scores[1].Score != 500 {
+				t.Fatalf("fetch got %+v", scores)
+			}
+			scores, err = backend.Fetch(ScoreQuery{Mode: gameModeMarathon})
+			if err != nil || len(scores) != 1 || scores[0].Name != "ann" {
+				t.Fatalf("fetch marathon got %+v, %v", scores, err)
+			}
+		})
+	}
+}
+
+func TestMemoryBackendError(t *testing.T) {
+	backend := &memoryBackend{}
+	down := errors.New("down")
+	backend.SetError(down)
+	if err := backend.Health(); !errors.Is(err, down) {
+		t.Fatalf("health: %v", err)
+	}
+	if err := backend.Submit(ScoreEntry{Name: "ann", Score: 1}); !errors.Is(err, down) {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := backend.Fetch(ScoreQuery{}); !errors.Is(err, down) {
+		t.Fatalf("fetch: %v", err)
+	}
+	backend.SetError(nil)
+	if err := backend.Submit(ScoreEntry{Name: "ann", Score: 1}); err != nil {
+		t.Fatal(err)
+	}
+	sync := &ScoreSync{enabled: true, backend: backend}
+	if scores, err := sync.FetchScores(ScoreQuery{}); err != nil || len(scores) != 1 {
+		t.Fatalf("sync fetch got %+v, %v", scores, err)
+	}
+}
+
+func TestNewScoreBackendErrors(t *testing.T) {
+	for _, config := range []Config{
+		{ScoreBackend: backendWebhook},
+		{ScoreBackend: backendREST},
+		{ScoreBackend: backendREST, ScoreAPIURL: "http://x", ScoreFields: "bogus=1"},
+		{ScoreBackend: backendFile},
+		{ScoreBackend: "memory"},
+	} {
+		if _, err := NewScoreBackend(config); err == nil {
+			t.Errorf("NewScoreBackend(%+v) did not fail", config)
+		}
+	}
+}
+
+func TestRESTBackendKeepsIDAndTime(t *testing.T) {
+	stub := newRESTStub(t)
+	backend, err := NewScoreBackend(Config{
+		ScoreBackend: backendREST,
+		ScoreAPIURL:  stub.URL,
+		ScoreAuth:    "Authorization",
+		ScoreAPIKey:  "Bearer k",
+		ScoreList:    "items",
+		ScoreFields:  "id=uuid,when=ts,played_at=ts",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := ScoreEntry{ID: newScoreID(), Name: "ann", Score: 700, When: "2024-05-06T07:08:09Z"}
+	if err := backend.Submit(entry); err != nil {
+		t.Fatal(err)
+	}
+	scores, err := backend.Fetch(ScoreQuery{})
+	if err != nil || len(scores) != 1 {
+		t.Fatalf("fetch got %+v, %v", scores, err)
+	}
+	if scores[0].ID != entry.ID || scores[0].When != entry.When {
+		t.Fatalf("round trip got id %q when %q", scores[0].ID, scores[0].When)
+	}
+	if !newScoreIndex(scores).has(entry) {
+		t.Fatal("fetched score does not dedupe against the submitted one")
+	}
+}
+
+func TestBackendsKeepMetadata(t *testing.T) {
+	stub := newRESTStub(t)
+	rest, err := NewScoreBackend(Config{
+		ScoreBackend: backendREST,
+		ScoreAPIURL:  stub.URL,
+		ScoreAuth:    "Authorization",
+		ScoreAPIKey:  "Bearer k",
+		ScoreList:    "items",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestScoreServer(t, "")
+	api := httptest.NewServer(server.Handler())
+	defer api.Close()
+	webhook, err := NewScoreBackend(Config{ScoreAPIURL: api.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := metadataRecord().ScoreEntry()
+	for name, backend := range map[string]ScoreBackend{"rest": rest, "webhook": webhook} {
+		if err := backend.Submit(entry); err != nil {
+			t.Fatalf("%s submit: %v", name, err)
+		}
+		scores, err := backend.Fetch(ScoreQuery{})
+		if err != nil || len(scores) != 1 {
+			t.Fatalf("%s fetch got %+v, %v", name, scores, err)
+		}
+		if !sameMetadata(entry, scores[0]) {
+			t.Errorf("%s lost metadata:\n%+v\n%+v", name, entry, scores[0])
+		}
+	}
+}
+
+func TestRESTBackendSendsQuery(t *testing.T) {
+	var got url.Values
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Header().Set("X-Total-Count", "1")
+		_ = json.NewEncoder(w).Encode([]map[string]any{{"name": "ann", "score": 10}})
+	}))
+	defer stub.Close()
+	backend, err := NewScoreBackend(Config{ScoreBackend: backendREST, ScoreAPIURL: stub.URL + "?table=scores"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores, err := backend.Fetch(ScoreQuery{Limit: 5, Offset: 10, Mode: gameModeBattle, Search: "an", Window: scoreWindowWeek})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 {
+		t.Fatalf("server-filtered page was filtered again: %+v", scores)
+	}
+	for key, want := range map[string]string{"table": "scores", "limit": "5", "offset": "10", "mode": gameModeBattle, "q": "an"} {
+		if got.Get(key) != want {
+			t.Errorf("%s = %q, want %q", key, got.Get(key), want)
+		}
+	}
+	if got.Get("since") == "" {
+		t.Error("since was not sent")
+	}
+}
+
+func TestFileBackendIDStaysInDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	backend, err := NewScoreBackend(Config{ScoreBackend: backendFile, ScoreDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := newScoreID()
+	for _, entry := range []ScoreEntry{
+		{ID: "../../escape", Name: "eve", Score: 1},
+		{ID: id, Name: "ann", Score: 2},
+	} {
+		if err := backend.Submit(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.json")); !os.IsNotExist(err) {
+		t.Fatal("an ID wrote a file outside score_dir")
+	}
+	if _, err := os.Stat(filepath.Join(dir, id+".json")); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("score_dir has %d files (%v), want 2", len(files), err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type opponentTickMsg struct {
+	round int
+}
+
+type Battle struct {
+	active     bool
+	round      int
+	difficulty int
+	opponent   Game
+	rng        *rand.Rand
+	won        bool
+}
+
+func NewBattle(round, difficulty int) Battle {
+	if difficulty < 0 || difficulty >= len(botDifficulties) {
+		difficulty = 0
+	}
+	return Battle{
+		active:     true,
+		round:      round,
+		difficulty: difficulty,
+		opponent:   NewGame(),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (b Battle) Difficulty() BotDifficulty {
+	return botDifficulties[b.difficulty]
+}
+
+func opponentTickCmd(round int, difficulty BotDifficulty) tea.Cmd {
+	interval := time.Second
+	if difficulty.PPS > 0 {
+		interval = time.Duration(float64(time.Second) / difficulty.PPS)
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return opponentTickMsg{round: round} })
+}
+
+func (m *Model) updateOpponent(msg opponentTickMsg) tea.Cmd {
+	if m.screen != screenGame || !m.battle.active || msg.round != m.battle.round || m.game.Over || m.battle.opponent.Over {
+		return nil
+	}
+	difficulty := m.battle.Difficulty()
+	if m.startCount > 0 || m.game.Paused {
+		return opponentTickCmd(m.battle.round, difficulty)
+	}
+	opponent := &m.battle.opponent
+	placement, ok := choosePlacement(opponent, difficulty.Lookahead, difficulty.Mistake, m.battle.rng)
+	if !ok {
+		opponent.Over = true
+	} else if result, placed := playPlacement(opponent, placement); placed {
+		opponent.ResolveLineClear()
+		attack := opponent.OffsetGarbage(result.Attack)
+		m.game.AddGarbage(attack)
+	} else if !opponent.Over {
+		opponent.Over = true
+	}
+	if opponent.Over {
+		m.battle.won = true
+		m.game.Over = true
+		m.game.StopClock()
+		cmd := m.showResults()
+		if m.config.Sound {
+			return tea.Batch(cmd, playSound(m.sound, SoundLine4))
+		}
+		return cmd
+	}
+	return opponentTickCmd(m.battle.round, difficulty)
+}
+
+func (m *Model) sendAttack(result LockResult) {
+	if !m.battle.active {
+		return
+	}
+	attack := m.game.OffsetGarbage(result.Attack)
+	m.battle.opponent.AddGarbage(attack)
+}
+
+func (m *Model) updateBattleSelect(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if m.battleIndex > 0 {
+			m.battleIndex--
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "down", "j":
+		if m.battleIndex < len(botDifficulties)-1 {
+			m.battleIndex++
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "enter":
+		cmd := m.startBattle(m.battleIndex)
+		if m.config.Sound {
+			return tea.Batch(cmd, playSound(m.sound, SoundMenuSelect))
+		}
+		return cmd
+	case "q", "esc":
+		return m.setScreen(screenMenu)
+	}
+	return nil
+}
+
+func (m *Model) startBattle(difficulty int) tea.Cmd {
+	m.game = NewGame()
+	m.battle = NewBattle(m.battle.round+1, difficulty)
+	m.startCount = 2
+	return tea.Batch(m.setScreen(screenGame), countdownTickCmd(), opponentTickCmd(m.battle.round, m.battle.Difficulty()), m.requestCoach())
+}
+
+func viewBattleSelect(m Model) string {
+	theme := themes[m.themeIndex]
+	items := make([]string, 0, len(botDifficulties))
+	for _, difficulty := range botDifficulties {
+		items = append(items, fmt.Sprintf("%-6s  %.1f PPS  lookahead %d", difficulty.Name, difficulty.PPS, difficulty.Lookahead))
+	}
+	content := renderMenu("Battle", items, m.battleIndex, "Enter to fight, Esc to back", theme)
+	return center(m.width, m.height, content)
+}
+
+func renderOpponent(m Model, theme Theme) string {
+	opponent := m.battle.opponent
+	board := renderBoard(opponent, theme, 1, false, nil, time.Time{}, time.Time{}, nil, nil, time.Time{}, time.Time{}, nil)
+	pad := lipgloss.NewStyle().PaddingLeft(2)
+	lines := []string{
+		pad.Render(titleStyle(theme).Render(fmt.Sprintf("CPU (%s)", m.battle.Difficulty().Name))),
+		pad.Render(board),
+		pad.Render(fmt.Sprintf("Score: %d  Lines: %d", opponent.Score, opponent.Lines)),
+	}
+	if opponent.Garbage > 0 {
+		lines = append(lines, pad.Render(warningStyle(theme).Render(fmt.Sprintf("Incoming: %d", opponent.Garbage))))
+	}
+	if m.game.Garbage > 0 {
+		lines = append(lines, pad.Render(warningStyle(theme).Render(fmt.Sprintf("You incoming: %d", m.game.Garbage))))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestOffsetGarbage(t *testing.T) {
+	tests := []struct {
+		pending, attack int
+		sent, remaining int
+	}{
+		{0, 3, 3, 0},
+		{4, 0, 0, 4},
+		{4, 1, 0, 3},
+		{2, 5, 3, 0},
+		{3, 3, 0, 0},
+	}
+	for _, test := range tests {
+		g := NewGameWithSeed(1)
+		g.Garbage = test.pending
+		if sent := g.OffsetGarbage(test.attack); sent != test.sent || g.Garbage != test.remaining {
+			t.Errorf("pending %d attack %d: sent %d, %d left; want %d, %d", test.pending, test.attack, sent, g.Garbage, test.sent, test.remaining)
+		}
+	}
+}
+
+func TestGarbageRisesOnLock(t *testing.T) {
+	g := NewGameWithSeed(1)
+	g.AddGarbage(2)
+	g.HardDrop()
+	if g.Garbage != 0 {
+		t.Fatalf("%d garbage lines still pending", g.Garbage)
+	}
+	for y := boardHeight - 2; y < boardHeight; y++ {
+		holes := 0
+		for _, cell := range g.Board[y] {
+			if cell == 0 {
+				holes++
+			} else if cell != garbageCell {
+				t.Fatalf("row %d holds a piece cell", y)
+			}
+		}
+		if holes != 1 {
+			t.Fatalf("row %d has %d holes, want 1", y, holes)
+		}
+	}
+}
+
+func TestSendAttackOnlyInBattle(t *testing.T) {
+	m := Model{game: NewGameWithSeed(1)}
+	m.sendAttack(LockResult{Attack: 4})
+	if m.battle.opponent.Garbage != 0 {
+		t.Fatal("sent garbage outside a battle")
+	}
+	m.battle = NewBattle(1, 0)
+	m.game.Garbage = 1
+	m.sendAttack(LockResult{Attack: 4})
+	if m.battle.opponent.Garbage != 3 || m.game.Garbage != 0 {
+		t.Fatalf("opponent got %d, player kept %d; want 3 and 0", m.battle.opponent.Garbage, m.game.Garbage)
+	}
+}
+
+func TestUpdateOpponent(t *testing.T) {
+	useTempDataDir(t)
+	m := Model{screen: screenGame, game: NewGameWithSeed(1), battle: NewBattle(2, 1)}
+	if cmd := m.updateOpponent(opponentTickMsg{round: 1}); cmd != nil || m.battle.opponent.Stats.Pieces != 0 {
+		t.Fatal("a tick from an earlier round moved the opponent")
+	}
+	if cmd := m.updateOpponent(opponentTickMsg{round: 2}); cmd == nil || m.battle.opponent.Stats.Pieces != 1 {
+		t.Fatalf("opponent placed %d pieces, want 1", m.battle.opponent.Stats.Pieces)
+	}
+	for y := range m.battle.opponent.Board {
+		for x := range m.battle.opponent.Board[y] {
+			m.battle.opponent.Board[y][x] = garbageCell
+		}
+	}
+	m.updateOpponent(opponentTickMsg{round: 2})
+	if !m.battle.won || !m.game.Over || m.screen != screenResults {
+		t.Fatalf("won %v, over %v, screen %v after the opponent topped out", m.battle.won, m.game.Over, m.screen)
+	}
+}
+
+func TestBotMistakes(t *testing.T) {
+	g := NewGameWithSeed(3)
+	best, ok := choosePlacement(&g, 1, 0, rand.New(rand.NewSource(1)))
+	if !ok {
+		t.Fatal("no placement")
+	}
+	if again, _ := choosePlacement(&g, 1, 0, rand.New(rand.NewSource(2))); again != best {
+		t.Fatal("a bot without mistakes chose differently")
+	}
+	rng := rand.New(rand.NewSource(1))
+	differs := false
+	for range 20 {
+		if placement, _ := choosePlacement(&g, 1, 1, rng); placement != best {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Fatal("a bot that always errs always chose the best placement")
+	}
+}
+
+func TestBotSurvives(t *testing.T) {
+	if g := playBot(5, 200); g.Over || g.Stats.Pieces != 200 {
+		t.Fatalf("bot topped out after %d pieces", g.Stats.Pieces)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Placement struct {
+	X        int
+	Y        int
+	Rotation int
+	Hold     bool
+	Spin     bool
+}
+
+type botWeights struct {
+	AggregateHeight float64
+	Lines           float64
+	Holes           float64
+	Bumpiness       float64
+}
+
+var defaultBotWeights = botWeights{
+	AggregateHeight: -0.510066,
+	Lines:           0.760666,
+	Holes:           -0.35663,
+	Bumpiness:       -0.184483,
+}
+
+type BotDifficulty struct {
+	Name      string
+	PPS       float64
+	Lookahead int
+	Mistake   float64
+}
+
+var botDifficulties = []BotDifficulty{
+	{Name: "Easy", PPS: 0.8, Lookahead: 0, Mistake: 0.25},
+	{Name: "Normal", PPS: 1.4, Lookahead: 1, Mistake: 0.08},
+	{Name: "Hard", PPS: 2.2, Lookahead: 1, Mistake: 0.02},
+}
+
+func (g *Game) simulate() Game {
+	board := make([][]int, boardHeight)
+	for y := range board {
+		board[y] = make([]int, boardWidth)
+		copy(board[y], g.Board[y])
+	}
+	sim := *g
+	sim.Board = board
+	sim.bag = nil
+	sim.pendingRows = nil
+	return sim
+}
+
+func botCandidates(g *Game, kind int) []Placement {
+	reachable := g.reachable(kind)
+	candidates := []Placement{}
+	for _, candidate := range dropCandidates(g, kind) {
+		if reachable.at(candidate.X, candidate.Y, candidate.Rotation) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// dropCandidates skips the reachability search; lookahead only uses it to
+// estimate how good a board is.
+func dropCandidates(g *Game, kind int) []Placement {
+	sim := g.simulate()
+	sim.Current = kind
+	seen := make(map[string]struct{})
+	candidates := []Placement{}
+	for rotation := 0; rotation < 4; rotation++ {
+		for x := -3; x < boardWidth; x++ {
+			if sim.collides(x, 0, rotation) {
+				continue
+			}
+			sim.X = x
+			sim.Y = 0
+			sim.Rotation = rotation
+			y := sim.GhostY()
+			key := placementKey(kind, x, y, rotation)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			candidates = append(candidates, Placement{X: x, Y: y, Rotation: rotation})
+		}
+	}
+	return candidates
+}
+
+func placementKey(kind, x, y, rotation int) string {
+	cells := make([]int, 0, 4)
+	for _, p := range pieceRotations[kind][rotation] {
+		cells = append(cells, (y+p.Y)*boardWidth+x+p.X)
+	}
+	sort.Ints(cells)
+	key := ""
+	for _, cell := range cells {
+		key += strconv.Itoa(cell) + ","
+	}
+	return key
+}
+
+func placeOnSimulation(g *Game, kind int, placement Placement) (Game, int) {
+	sim := g.simulate()
+	sim.Current = kind
+	sim.X = placement.X
+	sim.Y = placement.Y
+	sim.Rotation = placement.Rotation
+	sim.lockPiece()
+	rows := sim.fullRows()
+	sim.clearRows(rows)
+	return sim, len(rows)
+}
+
+func evaluateBoard(board [][]int, cleared int, weights botWeights) float64 {
+	heights := columnHeights(board)
+	aggregate := 0
+	bumpiness := 0
+	for x, height := range heights {
+		aggregate += height
+		if x > 0 {
+			diff := height - heights[x-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			bumpiness += diff
+		}
+	}
+	return weights.AggregateHeight*float64(aggregate) +
+		weights.Lines*float64(cleared) +
+		weights.Holes*float64(countHoles(board)) +
+		weights.Bumpiness*float64(bumpiness)
+}
+
+func columnHeights(board [][]int) []int {
+	heights := make([]int, boardWidth)
+	for x := 0; x < boardWidth; x++ {
+		for y := 0; y < boardHeight; y++ {
+			if board[y][x] != 0 {
+				heights[x] = boardHeight - y
+				break
+			}
+		}
+	}
+	return heights
+}
+
+func countHoles(board [][]int) int {
+	holes := 0
+	for x := 0; x < boardWidth; x++ {
+		covered := false
+		for y := 0; y < boardHeight; y++ {
+			if board[y][x] != 0 {
+				covered = true
+				continue
+			}
+			if covered {
+				holes++
+			}
+		}
+	}
+	return holes
+}
+
+func scorePlacement(g *Game, kind int, placement Placement, preview []int, weights botWeights) float64 {
+	sim, cleared := placeOnSimulation(g, kind, placement)
+	if len(preview) == 0 {
+		return evaluateBoard(sim.Board, cleared, weights)
+	}
+	best := math.Inf(-1)
+	for _, next := range dropCandidates(&sim, preview[0]) {
+		score := scorePlacement(&sim, preview[0], next, preview[1:], weights)
+		if score > best {
+			best = score
+		}
+	}
+	if math.IsInf(best, -1) {
+		return best
+	}
+	return best + weights.Lines*float64(cleared)
+}
+
+func choosePlacement(g *Game, lookahead int, mistake float64, rng *rand.Rand) (Placement, bool) {
+	type option struct {
+		kind    int
+		hold    bool
+		preview []int
+	}
+	options := []option{{kind: g.Current, preview: []int{g.Next}}}
+	if g.CanHold {
+		if g.HasHold {
+			options = append(options, option{kind: g.HoldKind, hold: true, preview: []int{g.Next}})
+		} else {
+			options = append(options, option{kind: g.Next, hold: true})
+		}
+	}
+	best := Placement{}
+	bestScore := math.Inf(-1)
+	all := []Placement{}
+	for _, opt := range options {
+		preview := opt.preview
+		if len(preview) > lookahead {
+			preview = preview[:lookahead]
+		}
+		for _, candidate := range botCandidates(g, opt.kind) {
+			candidate.Hold = opt.hold
+			all = append(all, candidate)
+			score := scorePlacement(g, opt.kind, candidate, preview, defaultBotWeights)
+			if score > bestScore {
+				bestScore = score
+				best = candidate
+			}
+		}
+	}
+	if len(all) == 0 {
+		return Placement{}, false
+	}
+	if rng != nil && mistake > 0 && rng.Float64() < mistake {
+		return all[rng.Intn(len(all))], true
+	}
+	return best, true
+}
+
+func playPlacement(g *Game, placement Placement) (LockResult, bool) {
+	if placement.Hold {
+		g.Hold()
+		if g.Over {
+			return LockResult{}, false
+		}
+	}
+	return g.Place(placement.X, placement.Y, placement.Rotation, placement.Spin)
+}
+
+func runBot(args []string) int {
+	flags := flag.NewFlagSet("bot", flag.ContinueOnError)
+	games := flags.Int("games", 1, "number of games to play")
+	maxPieces := flags.Int("max-pieces", 1000, "stop a game after this many pieces (0 for no limit)")
+	lookahead := flags.Int("lookahead", 1, "preview pieces considered per placement (0 or 1)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *games < 1 {
+		fmt.Fprintln(os.Stderr, "bot: --games must be at least 1")
+		return 2
+	}
+	totalScore := 0
+	totalLines := 0
+	totalPieces := 0
+	topOuts := 0
+	started := time.Now()
+	fmt.Printf("%4s  %8s  %6s  %5s  %6s  %s\n", "game", "score", "lines", "level", "pieces", "result")
+	for i := 1; i <= *games; i++ {
+		game := NewGame()
+		pieces := 0
+		for !game.Over && (*maxPieces == 0 || pieces < *maxPieces) {
+			placement, ok := choosePlacement(&game, *lookahead, 0, nil)
+			if !ok {
+				game.Over = true
+				break
+			}
+			if _, placed := playPlacement(&game, placement); !placed {
+				game.Over = true
+				break
+			}
+			game.ResolveLineClear()
+			pieces++
+		}
+		result := "limit"
+		if game.Over {
+			result = "top out"
+			topOuts++
+		}
+		fmt.Printf("%4d  %8d  %6d  %5d  %6d  %s\n", i, game.Score, game.Lines, game.Level, pieces, result)
+		totalScore += game.Score
+		totalLines += game.Lines
+		totalPieces += pieces
+	}
+	elapsed := time.Since(started)
+	fmt.Printf("\ngames: %d  top outs: %d  avg score: %d  avg lines: %.1f  avg pieces: %.1f\n",
+		*games, topOuts, totalScore / *games, float64(totalLines)/float64(*games), float64(totalPieces)/float64(*games))
+	if elapsed > 0 {
+		fmt.Printf("pieces/sec: %.0f  elapsed: %s\n", float64(totalPieces)/elapsed.Seconds(), elapsed.Round(time.Millisecond))
+	}
+	return 0
+}
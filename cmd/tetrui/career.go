@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	gameModeMarathon = "marathon"
+	gameModeBattle   = "battle"
+
+	careerRecentScores = 30
+	unnamedPlayer      = "Player"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+type PlayerStats struct {
+	Name       string         `json:"name"`
+	Games      int            `json:"games"`
+	PlayMs     int64          `json:"play_ms"`
+	Lines      int            `json:"lines"`
+	Pieces     int            `json:"pieces"`
+	Best       map[string]int `json:"best"`
+	Recent     []int          `json:"recent"`
+	LastPlayed string         `json:"last_played"`
+}
+
+func loadCareer() (map[string]PlayerStats, error) {
+	path, err := careerPath()
+	if err != nil {
+		return map[string]PlayerStats{}, err
+	}
+	career := map[string]PlayerStats{}
+	if _, err := readJSONFile(path, &career); os.IsNotExist(err) {
+		return map[string]PlayerStats{}, nil
+	} else if err != nil {
+		return map[string]PlayerStats{}, err
+	}
+	return career, nil
+}
+
+func saveCareer(career map[string]PlayerStats) error {
+	path, err := careerPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(career, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func recordCareerEntry(entry ScoreEntry) error {
+	path, err := careerPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	career, err := loadCareer()
+	if err != nil {
+		return err
+	}
+	return saveCareer(recordCareer(career, entry))
+}
+
+// careerEntry counts games that end without a saved name (retry, menu)
+// under the active profile.
+func careerEntry(record HistoryRecord) ScoreEntry {
+	entry := record.ScoreEntry()
+	if entry.Name == "" {
+		entry.Name = activeProfile
+	}
+	if entry.Name == "" {
+		entry.Name = unnamedPlayer
+	}
+	return entry
+}
+
+func careerPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stats.json"), nil
+}
+
+func recordCareer(career map[string]PlayerStats, entry ScoreEntry) map[string]PlayerStats {
+	key := strings.ToLower(entry.Name)
+	player := career[key]
+	player.Name = entry.Name
+	player.Games++
+	player.Lines += entry.Lines
+	if entry.Stats != nil {
+		player.PlayMs += entry.Stats.DurationMs
+		player.Pieces += entry.Stats.Pieces
+	}
+	mode := entry.Mode
+	if mode == "" {
+		mode = gameModeMarathon
+	}
+	if player.Best == nil {
+		player.Best = map[string]int{}
+	}
+	if entry.Score > player.Best[mode] {
+		player.Best[mode] = entry.Score
+	}
+	player.Recent = append(player.Recent, entry.Score)
+	if len(player.Recent) > careerRecentScores {
+		player.Recent = player.Recent[len(player.Recent)-careerRecentScores:]
+	}
+	player.LastPlayed = entry.When
+	career[key] = player
+	return career
+}
+
+func sortedPlayers(career map[string]PlayerStats) []PlayerStats {
+	players := make([]PlayerStats, 0, len(career))
+	for _, player := range career {
+		players = append(players, player)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].LastPlayed == players[j].LastPlayed {
+			return players[i].Name < players[j].Name
+		}
+		return players[i].LastPlayed > players[j].LastPlayed
+	})
+	return players
+}
+
+func sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, value := range values {
+		if value < min {
+			min = value
+		}
+		if value > max {
+			max = value
+		}
+	}
+	var b strings.Builder
+	for _, value := range values {
+		index := len(sparkBlocks) - 1
+		if max > min {
+			index = (value - min) * (len(sparkBlocks) - 1) / (max - min)
+		}
+		b.WriteRune(sparkBlocks[index])
+	}
+	return b.String()
+}
+
+func (m *Model) gameMode() string {
+	if m.battle.active {
+		return gameModeBattle
+	}
+	return gameModeMarathon
+}
+
+func (m *Model) openCareer() tea.Cmd {
+	career, err := loadCareer()
+	if err != nil {
+		DebugLogf("career load error: %v", err)
+	}
+	m.career = sortedPlayers(career)
+	m.careerIndex = 0
+	return m.setScreen(screenCareer)
+}
+
+func (m *Model) updateCareer(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "left", "h", "up", "k":
+		if m.careerIndex > 0 {
+			m.careerIndex--
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "right", "l", "down", "j":
+		if m.careerIndex < len(m.career)-1 {
+			m.careerIndex++
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "q", "esc", "enter":
+		return m.setScreen(screenMenu)
+	}
+	return nil
+}
+
+func viewCareer(m Model) string {
+	theme := themes[m.themeIndex]
+	var b strings.Builder
+	b.WriteString(titleStyle(theme).Render("Stats"))
+	b.WriteString("\n\n")
+	if len(m.career) == 0 {
+		b.WriteString("No games recorded yet.\n")
+		b.WriteString("\n")
+		b.WriteString(helpStyle(theme).Render("Enter to back"))
+		return center(m.width, m.height, b.String())
+	}
+	player := m.career[m.careerIndex]
+	b.WriteString(highlightStyle(theme).Render(player.Name))
+	b.WriteString(fmt.Sprintf("  (%d/%d)\n\n", m.careerIndex+1, len(m.career)))
+	b.WriteString(fmt.Sprintf("Games:      %d\n", player.Games))
+	b.WriteString(fmt.Sprintf("Play time:  %s\n", formatPlayTime(time.Duration(player.PlayMs)*time.Millisecond)))
+	b.WriteString(fmt.Sprintf("Lines:      %d\n", player.Lines))
+	b.WriteString(fmt.Sprintf("Pieces:     %d\n", player.Pieces))
+	b.WriteString(fmt.Sprintf("Last game:  %s\n", formatScoreTime(player.LastPlayed)))
+	b.WriteString("\n")
+	b.WriteString(titleStyle(theme).Render("Best"))
+	b.WriteString("\n")
+	modes := make([]string, 0, len(player.Best))
+	for mode := range player.Best {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		b.WriteString(fmt.Sprintf("%-10s  %d\n", mode, player.Best[mode]))
+	}
+	b.WriteString("\n")
+	b.WriteString(titleStyle(theme).Render(fmt.Sprintf("Last %d scores", len(player.Recent))))
+	b.WriteString("\n")
+	b.WriteString(highlightStyle(theme).Render(sparkline(player.Recent)))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle(theme).Render("Left/Right to switch player, Enter to back"))
+	return center(m.width, m.height, b.String())
+}
+
+func formatPlayTime(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %02dm", hours, minutes)
+	}
+	return formatDuration(d)
+}
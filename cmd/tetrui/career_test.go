@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestCareerCountsEveryGame(t *testing.T) {
+	useTempDataDir(t)
+	retry := Model{game: NewGameWithSeed(1)}
+	retry.game.Lines = 3
+	retry.recordHistory("")
+	retry.recordHistory("")
+	named := Model{game: NewGameWithSeed(2)}
+	named.game.Score = 900
+	named.recordHistory("ann")
+
+	career, err := loadCareer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player := career["player"]; player.Games != 1 || player.Lines != 3 {
+		t.Fatalf("unnamed game recorded as %+v", player)
+	}
+	if player := career["ann"]; player.Games != 1 || player.Best[gameModeMarathon] != 900 {
+		t.Fatalf("named game recorded as %+v", player)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import tea "github.com/charmbracelet/bubbletea"
+
+type coachMsg struct {
+	seq   int
+	cells []Point
+	hold  bool
+}
+
+func (m *Model) requestCoach() tea.Cmd {
+	m.coachSeq++
+	m.coachHint = nil
+	m.coachHold = false
+	if !m.config.Coach || m.screen != screenGame || m.game.Over || m.game.hasPendingLineClear() {
+		return nil
+	}
+	seq := m.coachSeq
+	snapshot := m.game.simulate()
+	return func() tea.Msg {
+		placement, ok := choosePlacement(&snapshot, 1, 0, nil)
+		if !ok {
+			return coachMsg{seq: seq}
+		}
+		kind := snapshot.Current
+		if placement.Hold {
+			kind = snapshot.Next
+			if snapshot.HasHold {
+				kind = snapshot.HoldKind
+			}
+		}
+		cells := make([]Point, 0, 4)
+		for _, p := range pieceRotations[kind][placement.Rotation] {
+			cells = append(cells, Point{X: placement.X + p.X, Y: placement.Y + p.Y})
+		}
+		return coachMsg{seq: seq, cells: cells, hold: placement.Hold}
+	}
+}
+
+func (m *Model) applyCoach(msg coachMsg) {
+	if msg.seq != m.coachSeq || !m.config.Coach {
+		return
+	}
+	m.coachHint = msg.cells
+	m.coachHold = msg.hold
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func coachModel() Model {
+	config := defaultConfig()
+	config.Coach = true
+	return Model{screen: screenGame, config: config, game: NewGameWithSeed(7)}
+}
+
+func runCoach(t *testing.T, m *Model) coachMsg {
+	t.Helper()
+	cmd := m.requestCoach()
+	if cmd == nil {
+		t.Fatal("no coach command")
+	}
+	msg, ok := cmd().(coachMsg)
+	if !ok {
+		t.Fatal("coach command did not return a coachMsg")
+	}
+	return msg
+}
+
+func TestCoachOff(t *testing.T) {
+	m := coachModel()
+	m.config.Coach = false
+	m.coachHint = []Point{{X: 1, Y: 1}}
+	if cmd := m.requestCoach(); cmd != nil || m.coachHint != nil {
+		t.Fatal("coach ran while turned off")
+	}
+	m.config.Coach = true
+	m.screen = screenMenu
+	if cmd := m.requestCoach(); cmd != nil {
+		t.Fatal("coach ran outside a game")
+	}
+}
+
+func TestCoachHintMatchesBot(t *testing.T) {
+	m := coachModel()
+	snapshot := m.game.simulate()
+	placement, ok := choosePlacement(&snapshot, 1, 0, nil)
+	if !ok {
+		t.Fatal("no placement")
+	}
+	msg := runCoach(t, &m)
+	if msg.hold != placement.Hold || len(msg.cells) != 4 {
+		t.Fatalf("hint %+v, bot chose %+v", msg, placement)
+	}
+	for _, cell := range msg.cells {
+		if cell.X < 0 || cell.X >= boardWidth || cell.Y < 0 || cell.Y >= boardHeight {
+			t.Fatalf("hint cell %+v is off the board", cell)
+		}
+	}
+	m.applyCoach(msg)
+	if !slices.Equal(m.coachHint, msg.cells) {
+		t.Fatal("hint was not applied")
+	}
+}
+
+func TestCoachUsesSnapshot(t *testing.T) {
+	m := coachModel()
+	want := runCoach(t, &m)
+	cmd := m.requestCoach()
+	for x := 0; x < boardWidth; x++ {
+		m.game.Board[boardHeight-1][x] = garbageCell
+	}
+	got := cmd().(coachMsg)
+	if !slices.Equal(got.cells, want.cells) {
+		t.Fatalf("hint %v changed with the live board, want %v", got.cells, want.cells)
+	}
+}
+
+func TestCoachIgnoresStaleHints(t *testing.T) {
+	m := coachModel()
+	stale := runCoach(t, &m)
+	m.game.Hold()
+	fresh := runCoach(t, &m)
+	m.applyCoach(stale)
+	if m.coachHint != nil {
+		t.Fatal("applied a hint from before the hold")
+	}
+	m.applyCoach(fresh)
+	if !slices.Equal(m.coachHint, fresh.cells) {
+		t.Fatal("fresh hint was not applied")
+	}
+}
+
+func TestRenderBoardDrawsHint(t *testing.T) {
+	g := NewGameWithSeed(1)
+	hint := []Point{{X: 0, Y: boardHeight - 1}, {X: 1, Y: boardHeight - 1}, {X: 2, Y: boardHeight - 1}, {X: 3, Y: boardHeight - 1}}
+	render := func(hint []Point) string {
+		return renderBoard(g, themes[0], 1, true, nil, time.Time{}, time.Time{}, nil, nil, time.Time{}, time.Time{}, hint)
+	}
+	if got := strings.Count(render(hint), "[]"); got != 4 {
+		t.Fatalf("drew %d hint cells, want 4", got)
+	}
+	if got := strings.Count(render(nil), "[]"); got != 0 {
+		t.Fatalf("drew %d hint cells without a hint", got)
+	}
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	configVersion   = 1
+	portableDirName = "tetrui-data"
+)
+
+type configOverride struct {
+	Source string
+	Key    string
+	Value  string
+}
+
+var (
+	configFile      string
+	portableMode    bool
+	configOverrides []configOverride
+)
+
+type Config struct {
+	Version       int    `json:"version"`
+	Theme         string `json:"theme"`
+	Sound         bool   `json:"sound"`
+	Music         bool   `json:"music"`
+	Shadow        bool   `json:"shadow"`
+	Animations    bool   `json:"animations"`
+	HardDropTrace bool   `json:"hard_drop_trace"`
+	Scale         int    `json:"scale"`
+	Sync          bool   `json:"sync"`
+	Volume        int    `json:"volume"`
+	Coach         bool   `json:"coach"`
+	StatsPanel    bool   `json:"stats_panel"`
+	ScoreBackend  string `json:"score_backend"`
+	ScoreAPIURL   string `json:"score_api_url"`
+	ScoreAPIKey   string `json:"score_api_key"`
+	ScoreAuth     string `json:"score_auth_header"`
+	ScoreList     string `json:"score_list_key"`
+	ScoreFields   string `json:"score_fields"`
+	ScoreDir      string `json:"score_dir"`
+	ScoreSecret   string `json:"score_secret"`
+	ScoreEvents   string `json:"score_events_url"`
+	KeyLeft       string `json:"key_left"`
+	KeyRight      string `json:"key_right"`
+	KeySoftDrop   string `json:"key_soft_drop"`
+	KeyHardDrop   string `json:"key_hard_drop"`
+	KeyRotateCW   string `json:"key_rotate_cw"`
+	KeyRotateCCW  string `json:"key_rotate_ccw"`
+	KeyHold       string `json:"key_hold"`
+	KeyPause      string `json:"key_pause"`
+}
+
+// configMigrations[n] upgrades a raw config from version n to n+1.
+var configMigrations = []func(raw map[string]json.RawMessage){
+	migrateConfigV0,
+}
+
+func defaultConfig() Config {
+	return Config{
+		Version:       configVersion,
+		Theme:         themes[0].Name,
+		Sound:         true,
+		Music:         true,
+		Shadow:        true,
+		Animations:    true,
+		HardDropTrace: true,
+		Scale:         1,
+		Sync:          true,
+		Volume:        70,
+		ScoreBackend:  backendWebhook,
+		ScoreAPIURL:   defaultScoreAPIURL,
+		ScoreAPIKey:   defaultScoreAPIKey,
+		ScoreSecret:   defaultScoreSecret,
+		KeyLeft:       "left,h",
+		KeyRight:      "right,l",
+		KeySoftDrop:   "down,j",
+		KeyHardDrop:   "space",
+		KeyRotateCW:   "up,x",
+		KeyRotateCCW:  "z",
+		KeyHold:       "c",
+		KeyPause:      "p",
+	}
+}
+
+func migrateConfigV0(raw map[string]json.RawMessage) {
+	// Version 0 configs may predate the shadow setting. A saved value,
+	// including false, is kept.
+	if _, ok := raw["shadow"]; !ok {
+		raw["shadow"] = json.RawMessage("true")
+	}
+}
+
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+func SetPortable(enabled bool) {
+	portableMode = enabled
+}
+
+func AddConfigOverride(source, key, value string) error {
+	if _, err := configValue(key, value); err != nil {
+		return err
+	}
+	configOverrides = append(configOverrides, configOverride{Source: source, Key: key, Value: value})
+	return nil
+}
+
+func portableDir() (string, bool) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	dir := filepath.Join(filepath.Dir(exe), portableDirName)
+	if enabled, _ := strconv.ParseBool(os.Getenv("TETRUI_PORTABLE")); portableMode || enabled {
+		return dir, true
+	}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, true
+	}
+	return "", false
+}
+
+func loadConfig() (Config, []string, error) {
+	config, issues, err := loadConfigFile()
+	issues = append(issues, applyConfigOverrides(&config)...)
+	return config, append(issues, validateConfig(&config)...), err
+}
+
+func loadConfigFile() (Config, []string, error) {
+	path, err := configPath()
+	if err != nil {
+		return defaultConfig(), nil, err
+	}
+	raw := map[string]json.RawMessage{}
+	issues := []string{}
+	_, err = readJSONFile(path, &raw)
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		issues = append(issues, fmt.Sprintf("%s is unreadable: %v", filepath.Base(path), err))
+	}
+	if err != nil || len(raw) == 0 {
+		raw = map[string]json.RawMessage{"version": json.RawMessage(strconv.Itoa(configVersion))}
+	}
+	config, decodeIssues := decodeConfig(raw)
+	issues = append(issues, decodeIssues...)
+	return config, append(issues, validateConfig(&config)...), err
+}
+
+func decodeConfig(raw map[string]json.RawMessage) (Config, []string) {
+	config := defaultConfig()
+	issues := []string{}
+	version := 0
+	if value, ok := raw["version"]; ok {
+		if err := json.Unmarshal(value, &version); err != nil || version < 0 {
+			issues = append(issues, fmt.Sprintf("version: invalid value %s", value))
+			version = 0
+		}
+	}
+	if version > configVersion {
+		issues = append(issues, fmt.Sprintf("version %d is newer than this build supports (%d)", version, configVersion))
+	}
+	for ; version < configVersion; version++ {
+		configMigrations[version](raw)
+	}
+	delete(raw, "version")
+	known := configKeys()
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			issues = append(issues, fmt.Sprintf("%s: unknown setting", key))
+			continue
+		}
+		field, _ := json.Marshal(map[string]json.RawMessage{key: raw[key]})
+		if err := json.Unmarshal(field, &config); err != nil {
+			issues = append(issues, fmt.Sprintf("%s: invalid value %s", key, raw[key]))
+		}
+	}
+	return config, issues
+}
+
+func envConfigOverrides() []configOverride {
+	keys := make([]string, 0)
+	for key := range configKeys() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	overrides := []configOverride{}
+	for _, key := range keys {
+		name := "TETRUI_" + strings.ToUpper(key)
+		if value, ok := os.LookupEnv(name); ok {
+			overrides = append(overrides, configOverride{Source: name, Key: key, Value: value})
+		}
+	}
+	return overrides
+}
+
+func applyConfigOverrides(config *Config) []string {
+	issues := []string{}
+	for _, override := range append(envConfigOverrides(), configOverrides...) {
+		value, err := configValue(override.Key, override.Value)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: %v", override.Source, err))
+			continue
+		}
+		field, _ := json.Marshal(map[string]json.RawMessage{override.Key: value})
+		_ = json.Unmarshal(field, config)
+	}
+	return issues
+}
+
+func overriddenConfigKeys() map[string]bool {
+	keys := map[string]bool{}
+	for _, override := range append(envConfigOverrides(), configOverrides...) {
+		keys[override.Key] = true
+	}
+	return keys
+}
+
+func configValue(key, text string) (json.RawMessage, error) {
+	def, ok := configFields(defaultConfig())[key]
+	if !ok || key == "version" {
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+	switch def[0] {
+	case '"':
+		return json.Marshal(text)
+	case 't', 'f':
+		switch strings.ToLower(text) {
+		case "on", "yes":
+			return json.RawMessage("true"), nil
+		case "off", "no":
+			return json.RawMessage("false"), nil
+		}
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not on/off (true/false)", text)
+		}
+		return json.RawMessage(strconv.FormatBool(value)), nil
+	default:
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		return json.RawMessage(strconv.Itoa(value)), nil
+	}
+}
+
+func validateConfig(config *Config) []string {
+	defaults := defaultConfig()
+	issues := []string{}
+	if themeIndexByName(config.Theme) < 0 {
+		issues = append(issues, fmt.Sprintf("theme: unknown theme %q, using %s", config.Theme, defaults.Theme))
+		config.Theme = defaults.Theme
+	}
+	if config.Scale != clampScale(config.Scale) {
+		issues = append(issues, fmt.Sprintf("scale: %d is out of range 1-3, using %d", config.Scale, clampScale(config.Scale)))
+		config.Scale = clampScale(config.Scale)
+	}
+	if config.Volume != clampVolumePercent(config.Volume) {
+		issues = append(issues, fmt.Sprintf("volume: %d is out of range 0-100, using %d", config.Volume, clampVolumePercent(config.Volume)))
+		config.Volume = clampVolumePercent(config.Volume)
+	}
+	if config.ScoreBackend == "" {
+		config.ScoreBackend = defaults.ScoreBackend
+	} else if !slices.Contains(scoreBackends, config.ScoreBackend) {
+		issues = append(issues, fmt.Sprintf("score_backend: unknown backend %q (choose from %s), using %s", config.ScoreBackend, strings.Join(scoreBackends, ", "), defaults.ScoreBackend))
+		config.ScoreBackend = defaults.ScoreBackend
+	}
+	issues = append(issues, validateKeys(config)...)
+	config.Version = configVersion
+	return issues
+}
+
+func configFields(config Config) map[string]json.RawMessage {
+	data, _ := json.Marshal(config)
+	fields := map[string]json.RawMessage{}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+func configKeys() map[string]bool {
+	fields := configFields(defaultConfig())
+	delete(fields, "version")
+	keys := make(map[string]bool, len(fields))
+	for key := range fields {
+		keys[key] = true
+	}
+	return keys
+}
+
+func saveConfig(config Config) error {
+	if overridden := overriddenConfigKeys(); len(overridden) > 0 {
+		onDisk, _, _ := loadConfigFile()
+		saved := configFields(onDisk)
+		fields := configFields(config)
+		for key := range overridden {
+			fields[key] = saved[key]
+		}
+		merged, _ := json.Marshal(fields)
+		_ = json.Unmarshal(merged, &config)
+	}
+	return writeConfigFile(config)
+}
+
+func writeConfigFile(config Config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	config.Version = configVersion
+	var saved any = config
+	// Built-in score settings are left out so the next release's values
+	// still apply; a setting the user changed is written as usual.
+	if embedded := embeddedSettings(); len(embedded) > 0 {
+		fields := configFields(config)
+		for key, value := range embedded {
+			if text, _ := json.Marshal(value); string(fields[key]) == string(text) {
+				delete(fields, key)
+			}
+		}
+		saved = fields
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func configPath() (string, error) {
+	if configFile != "" {
+		if err := os.MkdirAll(filepath.Dir(configFile), 0o755); err != nil {
+			return "", err
+		}
+		return configFile, nil
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+func runConfig(args []string) int {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	profile := flags.String("profile", "", "use this profile's config")
+	file := flags.String("config", "", "use this config file")
+	portable := flags.Bool("portable", false, "use the portable data dir next to the binary")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: tetrui config [flags] get <key> | set <key> <value> | list | reset | path")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *profile != "" {
+		if !validProfileName(*profile) {
+			fmt.Fprintf(os.Stderr, "config: invalid profile name %q\n", *profile)
+			return 2
+		}
+		SetProfile(*profile)
+	}
+	SetConfigFile(*file)
+	SetPortable(*portable)
+	rest := flags.Args()
+	if len(rest) == 0 {
+		flags.Usage()
+		return 2
+	}
+	switch {
+	case rest[0] == "path" && len(rest) == 1:
+		path, err := configPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			return 1
+		}
+		fmt.Println(path)
+	case rest[0] == "list" && len(rest) == 1:
+		config, issues, err := loadConfig()
+		printConfigIssues(issues)
+		if err != nil {
+			return 1
+		}
+		sources := map[string]string{}
+		for _, override := range append(envConfigOverrides(), configOverrides...) {
+			sources[override.Key] = override.Source
+		}
+		fields := configFields(config)
+		delete(fields, "version")
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			line := fmt.Sprintf("%s=%s", key, configDisplay(fields[key]))
+			if source, ok := sources[key]; ok {
+				line += fmt.Sprintf("  (from %s)", source)
+			}
+			fmt.Println(line)
+		}
+	case rest[0] == "get" && len(rest) == 2:
+		if !configKeys()[rest[1]] {
+			fmt.Fprintf(os.Stderr, "config: unknown setting %q\n", rest[1])
+			return 1
+		}
+		config, issues, err := loadConfig()
+		printConfigIssues(issues)
+		if err != nil {
+			return 1
+		}
+		fmt.Println(configDisplay(configFields(config)[rest[1]]))
+	case rest[0] == "set" && len(rest) == 3:
+		key, text := rest[1], rest[2]
+		value, err := configValue(key, text)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			return 1
+		}
+		if key == "theme" && themeIndexByName(text) < 0 {
+			names := make([]string, 0, len(themes))
+			for _, theme := range themes {
+				names = append(names, theme.Name)
+			}
+			fmt.Fprintf(os.Stderr, "config: unknown theme %q (choose from: %s)\n", text, strings.Join(names, ", "))
+			return 1
+		}
+		config, _, err := loadConfigFile()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v (run `tetrui config reset` to start over)\n", err)
+			return 1
+		}
+		field, _ := json.Marshal(map[string]json.RawMessage{key: value})
+		_ = json.Unmarshal(field, &config)
+		printConfigIssues(validateConfig(&config))
+		if err := writeConfigFile(config); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			return 1
+		}
+		if overriddenConfigKeys()[key] {
+			fmt.Fprintf(os.Stderr, "config: note: %s is overridden by the environment\n", key)
+		}
+	case rest[0] == "reset" && len(rest) == 1:
+		if err := writeConfigFile(defaultConfig()); err != nil {
+			fmt.Fprintf(os.Stderr, "config: %v\n", err)
+			return 1
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+	return 0
+}
+
+func configDisplay(value json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		return text
+	}
+	return string(value)
+}
+
+func printConfigIssues(issues []string) {
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "config: %s\n", issue)
+	}
+}
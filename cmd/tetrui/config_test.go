@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func decodeConfigJSON(t *testing.T, data string) (Config, []string) {
+	t.Helper()
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		t.Fatal(err)
+	}
+	return decodeConfig(raw)
+}
+
+func TestMigrateConfigV0Shadow(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"saved off", `{"theme": "Classic Tetris", "shadow": false}`, false},
+		{"saved on", `{"shadow": true}`, true},
+		{"missing", `{"theme": "Classic Tetris"}`, true},
+	}
+	for _, test := range tests {
+		config, issues := decodeConfigJSON(t, test.data)
+		if config.Shadow != test.want {
+			t.Errorf("%s: shadow = %v, want %v", test.name, config.Shadow, test.want)
+		}
+		if len(issues) > 0 {
+			t.Errorf("%s: issues %v", test.name, issues)
+		}
+	}
+}
+
+func TestDecodeConfigReportsIssues(t *testing.T) {
+	config, issues := decodeConfigJSON(t, `{"version": 1, "volume": "loud", "bogus": 1}`)
+	if config.Volume != defaultConfig().Volume {
+		t.Errorf("volume = %d", config.Volume)
+	}
+	if len(issues) != 2 {
+		t.Errorf("issues = %v", issues)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	useTempDataDir(t)
+	defaultScoreAPIURL = "http://embedded.example"
+	t.Cleanup(func() { defaultScoreAPIURL = "" })
+	t.Cleanup(func() { configOverrides = nil })
+	load := func() Config {
+		t.Helper()
+		config, _, err := loadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config
+	}
+	if got := load().ScoreAPIURL; got != defaultScoreAPIURL {
+		t.Fatalf("default url = %q, want the embedded one", got)
+	}
+	config := load()
+	config.Volume = 40
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	path, err := configPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]json.RawMessage
+	if _, err := readJSONFile(path, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved["score_api_url"]; ok {
+		t.Fatal("the embedded url was written to config.json")
+	}
+	config.ScoreAPIURL = "http://mine.example"
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); got.ScoreAPIURL != "http://mine.example" || got.Volume != 40 {
+		t.Fatalf("config.json lost to the defaults: url %q volume %d", got.ScoreAPIURL, got.Volume)
+	}
+	t.Setenv("TETRUI_SCORE_API_URL", "http://env.example")
+	if got := load().ScoreAPIURL; got != "http://env.example" {
+		t.Fatalf("env url = %q, want it to beat config.json", got)
+	}
+	if err := AddConfigOverride("--set", "score_api_url", "http://flag.example"); err != nil {
+		t.Fatal(err)
+	}
+	if got := load().ScoreAPIURL; got != "http://flag.example" {
+		t.Fatalf("flag url = %q, want it to beat the environment", got)
+	}
+}
+
+func resetConfigLayers(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() {
+		configOverrides = nil
+		SetConfigFile("")
+		SetPortable(false)
+	})
+}
+
+func TestEnvOverridesConfig(t *testing.T) {
+	useTempDataDir(t)
+	resetConfigLayers(t)
+	t.Setenv("TETRUI_VOLUME", "15")
+	t.Setenv("TETRUI_MUSIC", "off")
+	t.Setenv("TETRUI_THEME", themes[1].Name)
+	t.Setenv("TETRUI_SCALE", "big")
+	config, issues, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Volume != 15 || config.Music || config.Theme != themes[1].Name {
+		t.Fatalf("env not applied: volume %d music %v theme %q", config.Volume, config.Music, config.Theme)
+	}
+	if config.Scale != defaultConfig().Scale || len(issues) != 1 || !strings.HasPrefix(issues[0], "TETRUI_SCALE:") {
+		t.Fatalf("bad TETRUI_SCALE: scale %d, issues %v", config.Scale, issues)
+	}
+}
+
+func TestAddConfigOverrideValidates(t *testing.T) {
+	resetConfigLayers(t)
+	if err := AddConfigOverride("--set", "bogus", "1"); err == nil {
+		t.Error("accepted an unknown setting")
+	}
+	if err := AddConfigOverride("--volume", "volume", "loud"); err == nil {
+		t.Error("accepted a non-number volume")
+	}
+	if err := AddConfigOverride("--set", "version", "3"); err == nil {
+		t.Error("accepted the version field")
+	}
+	if len(configOverrides) != 0 {
+		t.Fatalf("rejected overrides were kept: %v", configOverrides)
+	}
+}
+
+func TestSaveConfigKeepsOverridesOffDisk(t *testing.T) {
+	useTempDataDir(t)
+	resetConfigLayers(t)
+	config := defaultConfig()
+	config.Volume = 40
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TETRUI_VOLUME", "10")
+	if err := AddConfigOverride("--no-music", "music", "false"); err != nil {
+		t.Fatal(err)
+	}
+	config, _, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Theme = themes[1].Name
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	saved, _, err := loadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Volume != 40 || !saved.Music || saved.Theme != themes[1].Name {
+		t.Fatalf("saved volume %d music %v theme %q; want 40, true and the new theme", saved.Volume, saved.Music, saved.Theme)
+	}
+}
+
+func TestConfigFileFlag(t *testing.T) {
+	useTempDataDir(t)
+	resetConfigLayers(t)
+	path := filepath.Join(t.TempDir(), "kit", "settings.json")
+	SetConfigFile(path)
+	config := defaultConfig()
+	config.Volume = 25
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("config not written to --config path: %v", err)
+	}
+	if config, _, _ := loadConfig(); config.Volume != 25 {
+		t.Fatalf("volume %d, want 25 from the --config file", config.Volume)
+	}
+}
+
+func TestPortableDataDir(t *testing.T) {
+	useTempDataDir(t)
+	resetConfigLayers(t)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	want := filepath.Join(filepath.Dir(exe), portableDirName)
+	if _, err := os.Stat(want); err == nil {
+		t.Skip("a portable data dir already exists next to the test binary")
+	}
+	t.Cleanup(func() { os.RemoveAll(want) })
+	SetPortable(true)
+	dir, err := dataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != want {
+		t.Fatalf("data dir %q, want %q", dir, want)
+	}
+}
+
+func captureStdout(t *testing.T, run func()) string {
+	t.Helper()
+	read, write, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = write
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(read)
+		done <- string(data)
+	}()
+	run()
+	write.Close()
+	return <-done
+}
+
+func TestConfigCommandSetAndGet(t *testing.T) {
+	useTempDataDir(t)
+	resetConfigLayers(t)
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"set", "volume", "150"}, 0},
+		{[]string{"set", "music", "off"}, 0},
+		{[]string{"set", "theme", themes[1].Name}, 0},
+		{[]string{"set", "theme", "Neon Nowhere"}, 1},
+		{[]string{"set", "scale", "big"}, 1},
+		{[]string{"set", "bogus", "1"}, 1},
+		{[]string{"get", "bogus"}, 1},
+		{[]string{"set", "volume"}, 2},
+	}
+	for _, test := range tests {
+		if code := runConfig(test.args); code != test.code {
+			t.Errorf("config %v: exit %d, want %d", test.args, code, test.code)
+		}
+	}
+	saved, _, err := loadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Volume != 100 || saved.Music || saved.Theme != themes[1].Name || saved.Scale != defaultConfig().Scale {
+		t.Fatalf("saved volume %d music %v theme %q scale %d", saved.Volume, saved.Music, saved.Theme, saved.Scale)
+	}
+	if got := captureStdout(t, func() { runConfig([]string{"get", "theme"}) }); got != themes[1].Name+"\n" {
+		t.Fatalf("get theme printed %q", got)
+	}
+	t.Setenv("TETRUI_VOLUME", "5")
+	list := captureStdout(t, func() { runConfig([]string{"list"}) })
+	if !strings.Contains(list, "volume=5  (from TETRUI_VOLUME)\n") || !strings.Contains(list, "music=false\n") {
+		t.Fatalf("list printed:\n%s", list)
+	}
+}
+
+func TestConfigCommandReset(t *testing.T) {
+	useTempDataDir(t)
+	resetConfigLayers(t)
+	if code := runConfig([]string{"set", "volume", "20"}); code != 0 {
+		t.Fatalf("set exit %d", code)
+	}
+	if code := runConfig([]string{"reset"}); code != 0 {
+		t.Fatalf("reset exit %d", code)
+	}
+	saved, _, err := loadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Volume != defaultConfig().Volume {
+		t.Fatalf("volume %d after reset", saved.Volume)
+	}
+	path, err := configPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := captureStdout(t, func() { runConfig([]string{"path"}) }); got != path+"\n" {
+		t.Fatalf("path printed %q, want %q", got, path)
+	}
+}
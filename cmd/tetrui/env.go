@@ -1,21 +1,32 @@
 package main
 
-import "os"
+import (
+	"runtime"
+)
 
 var (
+	version            = "dev"
 	defaultScoreAPIURL string
 	defaultScoreAPIKey string
+	defaultScoreSecret string
 )
 
-func loadEmbeddedEnv() {
-	if defaultScoreAPIURL != "" {
-		if _, exists := os.LookupEnv("TETRUI_SCORE_API_URL"); !exists {
-			_ = os.Setenv("TETRUI_SCORE_API_URL", defaultScoreAPIURL)
-		}
-	}
-	if defaultScoreAPIKey != "" {
-		if _, exists := os.LookupEnv("TETRUI_SCORE_API_KEY"); !exists {
-			_ = os.Setenv("TETRUI_SCORE_API_KEY", defaultScoreAPIKey)
+// embeddedSettings are the score settings set with -ldflags at build time.
+// They are defaults, so config.json, the environment and flags all win.
+func embeddedSettings() map[string]string {
+	settings := map[string]string{}
+	for key, value := range map[string]string{
+		"score_api_url": defaultScoreAPIURL,
+		"score_api_key": defaultScoreAPIKey,
+		"score_secret":  defaultScoreSecret,
+	} {
+		if value != "" {
+			settings[key] = value
 		}
 	}
+	return settings
+}
+
+func platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
 }
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type finesseState struct {
+	X        int
+	Rotation int
+}
+
+func emptyBoardGame(kind int) Game {
+	board := make([][]int, boardHeight)
+	for y := range board {
+		board[y] = make([]int, boardWidth)
+	}
+	return Game{Board: board, Current: kind, HoldKind: -1}
+}
+
+func finesseDistances(kind int) map[finesseState]int {
+	g := emptyBoardGame(kind)
+	start := finesseState{X: spawnX, Rotation: spawnRotation}
+	distances := map[finesseState]int{start: 0}
+	queue := []finesseState{start}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		next := []finesseState{}
+		for _, dx := range []int{-1, 1} {
+			if !g.collides(state.X+dx, 0, state.Rotation) {
+				next = append(next, finesseState{X: state.X + dx, Rotation: state.Rotation})
+			}
+		}
+		for _, dir := range []int{1, -1} {
+			g.X = state.X
+			g.Y = 0
+			g.Rotation = state.Rotation
+			g.Rotate(dir)
+			if g.Rotation != state.Rotation {
+				next = append(next, finesseState{X: g.X, Rotation: g.Rotation})
+			}
+		}
+		for _, candidate := range next {
+			if _, seen := distances[candidate]; seen {
+				continue
+			}
+			distances[candidate] = distances[state] + 1
+			queue = append(queue, candidate)
+		}
+	}
+	return distances
+}
+
+func normalizedColumns(kind, x, rotation int) string {
+	minY := boardHeight
+	for _, p := range pieceRotations[kind][rotation] {
+		if p.Y < minY {
+			minY = p.Y
+		}
+	}
+	cells := make([]string, 0, 4)
+	for _, p := range pieceRotations[kind][rotation] {
+		cells = append(cells, fmt.Sprintf("%d:%d", x+p.X, p.Y-minY))
+	}
+	sort.Strings(cells)
+	return strings.Join(cells, ",")
+}
+
+func finesseMinimal(kind, x, rotation int) int {
+	target := normalizedColumns(kind, x, rotation)
+	best := -1
+	for state, distance := range finesseDistances(kind) {
+		if normalizedColumns(kind, state.X, state.Rotation) != target {
+			continue
+		}
+		if best < 0 || distance < best {
+			best = distance
+		}
+	}
+	return best
+}
+
+type pieceState struct {
+	X        int
+	Y        int
+	Rotation int
+	Rotated  bool
+}
+
+// reachSet is indexed by x+reachPad, y, rotation and whether the last step
+// was a rotation, so the search avoids map lookups in the bot's inner loop.
+type reachSet [boardWidth + reachPad][boardHeight][4][2]bool
+
+const reachPad = 4
+
+func (r *reachSet) add(s pieceState) bool {
+	rotated := 0
+	if s.Rotated {
+		rotated = 1
+	}
+	seen := &r[s.X+reachPad][s.Y][s.Rotation][rotated]
+	if *seen {
+		return false
+	}
+	*seen = true
+	return true
+}
+
+func (r *reachSet) has(x, y, rotation int, rotated bool) bool {
+	if x+reachPad < 0 || x+reachPad >= len(r) || y < 0 || y >= boardHeight || rotation < 0 || rotation > 3 {
+		return false
+	}
+	if rotated {
+		return r[x+reachPad][y][rotation][1]
+	}
+	return r[x+reachPad][y][rotation][0]
+}
+
+func (r *reachSet) at(x, y, rotation int) bool {
+	return r.has(x, y, rotation, false) || r.has(x, y, rotation, true)
+}
+
+// reachable walks every position the piece can get to with moves, rotations
+// and soft drops, starting where it is now (or at spawn when it is not the
+// current piece). Rotated marks positions whose last step was a rotation.
+func (g *Game) reachable(kind int) *reachSet {
+	sim := Game{Board: g.Board, Current: kind}
+	start := pieceState{X: spawnX, Rotation: spawnRotation}
+	if kind == g.Current {
+		start = pieceState{X: g.X, Y: g.Y, Rotation: g.Rotation}
+	}
+	states := &reachSet{}
+	if sim.collides(start.X, start.Y, start.Rotation) {
+		return states
+	}
+	states.add(start)
+	queue := []pieceState{start}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		sim.nextStates(state, func(next pieceState, _ pieceMove) {
+			if states.add(next) {
+				queue = append(queue, next)
+			}
+		})
+	}
+	return states
+}
+
+type pieceMove int
+
+const (
+	moveLeft pieceMove = iota
+	moveRight
+	moveDown
+	moveRotateCW
+	moveRotateCCW
+)
+
+// nextStates calls visit for every state one move, soft drop or kicked
+// rotation away from state.
+func (g *Game) nextStates(state pieceState, visit func(pieceState, pieceMove)) {
+	if !g.collides(state.X-1, state.Y, state.Rotation) {
+		visit(pieceState{X: state.X - 1, Y: state.Y, Rotation: state.Rotation}, moveLeft)
+	}
+	if !g.collides(state.X+1, state.Y, state.Rotation) {
+		visit(pieceState{X: state.X + 1, Y: state.Y, Rotation: state.Rotation}, moveRight)
+	}
+	if !g.collides(state.X, state.Y+1, state.Rotation) {
+		visit(pieceState{X: state.X, Y: state.Y + 1, Rotation: state.Rotation}, moveDown)
+	}
+	if x, rotation, ok := g.kick(state.X, state.Y, state.Rotation, 1); ok {
+		visit(pieceState{X: x, Y: state.Y, Rotation: rotation, Rotated: true}, moveRotateCW)
+	}
+	if x, rotation, ok := g.kick(state.X, state.Y, state.Rotation, -1); ok {
+		visit(pieceState{X: x, Y: state.Y, Rotation: rotation, Rotated: true}, moveRotateCCW)
+	}
+}
+
+// pathTo returns the shortest list of moves that brings the current piece to
+// rest at target, using the same search as reachable.
+func (g *Game) pathTo(target Placement) ([]pieceMove, bool) {
+	type step struct {
+		from pieceState
+		move pieceMove
+	}
+	start := pieceState{X: g.X, Y: g.Y, Rotation: g.Rotation}
+	done := func(s pieceState) bool {
+		return s.X == target.X && s.Y == target.Y && s.Rotation == target.Rotation && (s.Rotated || !target.Spin)
+	}
+	steps := map[pieceState]step{start: {}}
+	queue := []pieceState{start}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		if done(state) {
+			moves := []pieceMove{}
+			for state != start {
+				moves = append(moves, steps[state].move)
+				state = steps[state].from
+			}
+			slices.Reverse(moves)
+			return moves, true
+		}
+		g.nextStates(state, func(next pieceState, move pieceMove) {
+			if _, seen := steps[next]; !seen {
+				steps[next] = step{from: state, move: move}
+				queue = append(queue, next)
+			}
+		})
+	}
+	return nil, false
+}
+
+// reaches reports whether kind can come to rest at placement. A spin must
+// have a rotation as its last step.
+func (g *Game) reaches(kind int, placement Placement) bool {
+	reach := g.reachable(kind)
+	if placement.Spin {
+		return reach.has(placement.X, placement.Y, placement.Rotation, true)
+	}
+	return reach.at(placement.X, placement.Y, placement.Rotation)
+}
+
+func (g *Game) finesseFault() int {
+	if g.pieceInputs == 0 {
+		return 0
+	}
+	sim := g.simulate()
+	sim.X = g.X
+	sim.Y = 0
+	sim.Rotation = g.Rotation
+	if sim.collides(sim.X, 0, sim.Rotation) || sim.GhostY() != g.Y {
+		return 0
+	}
+	minimal := finesseMinimal(g.Current, g.X, g.Rotation)
+	if minimal < 0 || g.pieceInputs <= minimal {
+		return 0
+	}
+	return g.pieceInputs - minimal
+}
+
+func (g *Game) PieceInputs() int {
+	return g.pieceInputs
+}
+
+func (g *Game) bufferedMove(dx int) bool {
+	inputs := g.pieceInputs
+	keys := g.Stats.Keys
+	moved := g.Move(dx)
+	g.pieceInputs = inputs
+	g.Stats.Keys = keys
+	return moved
+}
+
+func (m *Model) startTrainer() {
+	m.game = emptyBoardGame(0)
+	m.game.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	m.game.refillBag()
+	m.game.Current = m.game.popBag()
+	m.game.Next = m.game.popBag()
+	m.game.spawn()
+	m.battle = Battle{round: m.battle.round}
+	m.trainerRun = 0
+	m.trainerMiss = 0
+	m.trainerNote = ""
+	m.pickTrainerGoal()
+}
+
+func (m *Model) pickTrainerGoal() {
+	candidates := botCandidates(&m.game, m.game.Current)
+	if len(candidates) == 0 {
+		m.trainerGoal = nil
+		return
+	}
+	goal := candidates[m.game.rng.Intn(len(candidates))]
+	m.trainerGoal = make([]Point, 0, 4)
+	for _, p := range pieceRotations[m.game.Current][goal.Rotation] {
+		m.trainerGoal = append(m.trainerGoal, Point{X: goal.X + p.X, Y: goal.Y + p.Y})
+	}
+	m.trainerNeed = finesseMinimal(m.game.Current, goal.X, goal.Rotation)
+}
+
+func (m *Model) resetTrainerBoard(kind int) {
+	for y := range m.game.Board {
+		for x := range m.game.Board[y] {
+			m.game.Board[y][x] = 0
+		}
+	}
+	m.game.pendingRows = nil
+	m.game.Current = kind
+	m.game.spawn()
+}
+
+func (m *Model) updateTrainer(msg tea.KeyMsg) tea.Cmd {
+	switch m.keyAction(msg) {
+	case actionLeft:
+		if m.game.Move(-1) && m.config.Sound {
+			return playSound(m.sound, SoundMove)
+		}
+	case actionRight:
+		if m.game.Move(1) && m.config.Sound {
+			return playSound(m.sound, SoundMove)
+		}
+	case actionRotateCW:
+		m.game.Rotate(1)
+		if m.config.Sound {
+			return playSound(m.sound, SoundRotate)
+		}
+	case actionRotateCCW:
+		m.game.Rotate(-1)
+		if m.config.Sound {
+			return playSound(m.sound, SoundRotate)
+		}
+	case actionHardDrop:
+		kind := m.game.Current
+		inputs := m.game.PieceInputs()
+		ghostY := m.game.GhostY()
+		placed := make(map[Point]struct{}, 4)
+		for _, p := range pieceRotations[kind][m.game.Rotation] {
+			placed[Point{X: m.game.X + p.X, Y: ghostY + p.Y}] = struct{}{}
+		}
+		onTarget := len(placed) == len(m.trainerGoal)
+		for _, p := range m.trainerGoal {
+			if _, ok := placed[p]; !ok {
+				onTarget = false
+			}
+		}
+		m.game.HardDrop()
+		switch {
+		case !onTarget:
+			m.trainerMiss++
+			m.trainerRun = 0
+			m.trainerNote = "Wrong spot, try again"
+			m.resetTrainerBoard(kind)
+		case inputs > m.trainerNeed:
+			m.trainerMiss++
+			m.trainerRun = 0
+			m.trainerNote = fmt.Sprintf("%d inputs, finesse is %d. Again!", inputs, m.trainerNeed)
+			m.resetTrainerBoard(kind)
+		default:
+			m.trainerRun++
+			m.trainerNote = "Perfect!"
+			m.resetTrainerBoard(m.game.Current)
+			m.pickTrainerGoal()
+			if m.config.Sound {
+				return playSound(m.sound, SoundLine1)
+			}
+			return nil
+		}
+		if m.config.Sound {
+			return playSound(m.sound, SoundDrop)
+		}
+	default:
+		if slices.Contains(reservedKeys, msg.String()) {
+			return m.setScreen(screenMenu)
+		}
+	}
+	return nil
+}
+
+func viewTrainer(m Model) string {
+	theme := themes[m.themeIndex]
+	if theme.Name == levelShiftThemeName {
+		theme = themes[0]
+	}
+	board := renderBoard(m.game, theme, 1, m.config.Shadow, nil, time.Time{}, time.Time{}, nil, nil, time.Time{}, time.Time{}, m.trainerGoal)
+	pad := lipgloss.NewStyle().PaddingLeft(2)
+	lines := []string{
+		pad.Render(titleStyle(theme).Render("Finesse Trainer")),
+		"",
+		pad.Render("Drop the piece into the outline"),
+		pad.Render("with the fewest inputs."),
+		"",
+		pad.Render(fmt.Sprintf("Inputs: %d / %d", m.game.PieceInputs(), m.trainerNeed)),
+		pad.Render(fmt.Sprintf("Streak: %d", m.trainerRun)),
+		pad.Render(fmt.Sprintf("Misses: %d", m.trainerMiss)),
+	}
+	if m.trainerNote != "" {
+		lines = append(lines, "", pad.Render(highlightStyle(theme).Render(m.trainerNote)))
+	}
+	lines = append(lines,
+		"",
+		pad.Render(helpStyle(theme).Render(fmt.Sprintf("%s, %s: move", keysLabel(m.config.KeyLeft), keysLabel(m.config.KeyRight)))),
+		pad.Render(helpStyle(theme).Render(fmt.Sprintf("%s, %s: rotate", keysLabel(m.config.KeyRotateCW), keysLabel(m.config.KeyRotateCCW)))),
+		pad.Render(helpStyle(theme).Render(keysLabel(m.config.KeyHardDrop)+": drop")),
+		pad.Render(helpStyle(theme).Render("Q: menu")),
+	)
+	info := lipgloss.JoinVertical(lipgloss.Left, lines...)
+	return center(m.width, m.height, lipgloss.JoinHorizontal(lipgloss.Top, board, info))
+}
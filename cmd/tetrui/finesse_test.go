@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFinesseMinimal(t *testing.T) {
+	tests := []struct {
+		name           string
+		kind, x, turns int
+		want           int
+	}{
+		{"T at spawn", 2, spawnX, 0, 0},
+		{"T one left", 2, spawnX - 1, 0, 1},
+		{"T two right", 2, spawnX + 2, 0, 2},
+		{"T turned once", 2, spawnX, 1, 1},
+		{"T turned twice", 2, spawnX, 2, 2},
+		{"T turned back", 2, spawnX, 3, 1},
+	}
+	for _, test := range tests {
+		if got := finesseMinimal(test.kind, test.x, test.turns); got != test.want {
+			t.Errorf("%s: %d inputs, want %d", test.name, got, test.want)
+		}
+	}
+	for kind := range pieceRotations {
+		if got := finesseMinimal(kind, spawnX, spawnRotation); got != 0 {
+			t.Errorf("%s at spawn: %d inputs, want 0", pieceNames[kind], got)
+		}
+	}
+}
+
+func TestFinesseFaults(t *testing.T) {
+	g := NewGameWithSeed(1)
+	g.Move(-1)
+	if result := g.HardDrop(); result.Finesse != 0 {
+		t.Fatalf("a one-tap move cost %d faults", result.Finesse)
+	}
+	g.Move(-1)
+	g.Move(1)
+	g.Move(-1)
+	if result := g.HardDrop(); result.Finesse != 2 {
+		t.Fatalf("three taps for one column cost %d faults, want 2", result.Finesse)
+	}
+	g.Rotate(1)
+	g.Rotate(-1)
+	if result := g.HardDrop(); result.Finesse != 2 {
+		t.Fatalf("a wasted turn cost %d faults, want 2", result.Finesse)
+	}
+	if g.Finesse != 4 {
+		t.Fatalf("game total %d faults, want 4", g.Finesse)
+	}
+}
+
+func TestBufferedMoveIsNotAnInput(t *testing.T) {
+	g := NewGameWithSeed(1)
+	if !g.bufferedMove(-1) || g.PieceInputs() != 0 || g.Stats.Keys != 0 {
+		t.Fatalf("buffered move counted: %d inputs, %d keys", g.PieceInputs(), g.Stats.Keys)
+	}
+}
+
+// aimTrainerAtSpawn makes the goal the current piece dropped straight down.
+func aimTrainerAtSpawn(m *Model) {
+	m.trainerGoal = nil
+	for _, p := range pieceRotations[m.game.Current][m.game.Rotation] {
+		m.trainerGoal = append(m.trainerGoal, Point{X: m.game.X + p.X, Y: m.game.GhostY() + p.Y})
+	}
+	m.trainerNeed = finesseMinimal(m.game.Current, m.game.X, m.game.Rotation)
+}
+
+func TestTrainerNeedsFinesse(t *testing.T) {
+	m := Model{screen: screenTrainer, config: defaultConfig()}
+	m.startTrainer()
+	drop := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+
+	aimTrainerAtSpawn(&m)
+	m.updateTrainer(drop)
+	if m.trainerRun != 1 || m.trainerMiss != 0 {
+		t.Fatalf("run %d, misses %d after a perfect drop", m.trainerRun, m.trainerMiss)
+	}
+
+	aimTrainerAtSpawn(&m)
+	kind := m.game.Current
+	m.updateTrainer(tea.KeyMsg{Type: tea.KeyLeft})
+	m.updateTrainer(tea.KeyMsg{Type: tea.KeyRight})
+	m.updateTrainer(drop)
+	if m.trainerRun != 0 || m.trainerMiss != 1 || !strings.Contains(m.trainerNote, "finesse is 0") {
+		t.Fatalf("run %d, misses %d, note %q after wasted inputs", m.trainerRun, m.trainerMiss, m.trainerNote)
+	}
+	if m.game.Current != kind || m.game.PieceInputs() != 0 || m.game.Board[boardHeight-1][m.trainerGoal[0].X] != 0 {
+		t.Fatal("a miss did not reset the board for another try")
+	}
+
+	m.updateTrainer(tea.KeyMsg{Type: tea.KeyLeft})
+	m.updateTrainer(drop)
+	if m.trainerMiss != 2 || m.trainerNote != "Wrong spot, try again" {
+		t.Fatalf("misses %d, note %q after missing the target", m.trainerMiss, m.trainerNote)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 )
@@ -9,6 +10,12 @@ const (
 	boardWidth  = 10
 	boardHeight = 20
 	lockDelay   = 250 * time.Millisecond
+	garbageCell = 8
+
+	spawnX        = 3
+	spawnRotation = 0
+
+	ruleSet = "srs-7bag"
 )
 
 var levelFallIntervals = []time.Duration{
@@ -62,28 +69,45 @@ type Game struct {
 	pendingRows []int
 	Combo       int
 	BackToBack  int
+	Garbage     int
+	pieceInputs int
+	Finesse     int
+	Stats       GameStats
+	clockStart  time.Time
+	clockStop   time.Time
+	pausedAt    time.Time
+	pausedFor   time.Duration
+	Seed        int64
+	inputs      []byte
 }
 
 type LockResult struct {
-	Locked      bool
-	Cleared     int
-	ScoreDelta  int
-	TSpin       bool
-	ClearedRows []int
-	Combo       int
-	BackToBack  int
+	Locked       bool
+	Cleared      int
+	ScoreDelta   int
+	TSpin        bool
+	ClearedRows  []int
+	Combo        int
+	BackToBack   int
+	Finesse      int
+	Attack       int
+	PerfectClear bool
 }
 
 func NewGame() Game {
+	return NewGameWithSeed(time.Now().UnixNano())
+}
+
+func NewGameWithSeed(seed int64) Game {
 	board := make([][]int, boardHeight)
 	for i := range board {
 		board[i] = make([]int, boardWidth)
 	}
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	game := Game{
 		Board:    board,
 		HoldKind: -1,
-		rng:      rng,
+		rng:      rand.New(rand.NewSource(seed)),
+		Seed:     seed,
 	}
 	game.refillBag()
 	game.Current = game.popBag()
@@ -92,6 +116,10 @@ func NewGame() Game {
 	return game
 }
 
+func boardSize() string {
+	return fmt.Sprintf("%dx%d", boardWidth, boardHeight)
+}
+
 func (g *Game) FallInterval() time.Duration {
 	if g.Level < 0 {
 		return levelFallIntervals[0]
@@ -106,6 +134,9 @@ func (g *Game) Move(dx int) bool {
 	if g.Over || g.Paused || g.hasPendingLineClear() {
 		return false
 	}
+	g.pieceInputs++
+	g.Stats.Keys++
+	g.logMove(dx)
 	if !g.collides(g.X+dx, g.Y, g.Rotation) {
 		g.X += dx
 		g.resetLock()
@@ -119,6 +150,8 @@ func (g *Game) SoftDrop() {
 	if g.Over || g.Paused || g.hasPendingLineClear() {
 		return
 	}
+	g.Stats.Keys++
+	g.logInput(inputSoftDrop)
 	if !g.collides(g.X, g.Y+1, g.Rotation) {
 		g.Y++
 		g.Score++
@@ -131,6 +164,8 @@ func (g *Game) HardDrop() LockResult {
 	if g.Over || g.Paused || g.hasPendingLineClear() {
 		return LockResult{}
 	}
+	g.Stats.Keys++
+	g.logInput(inputHardDrop)
 	distance := 0
 	for !g.collides(g.X, g.Y+1, g.Rotation) {
 		g.Y++
@@ -148,28 +183,33 @@ func (g *Game) Rotate(dir int) {
 	if g.Over || g.Paused || g.hasPendingLineClear() {
 		return
 	}
-	newRot := (g.Rotation + dir + 4) % 4
-	if !g.collides(g.X, g.Y, newRot) {
-		g.Rotation = newRot
+	g.pieceInputs++
+	g.Stats.Keys++
+	g.logRotate(dir)
+	if x, rotation, ok := g.kick(g.X, g.Y, g.Rotation, dir); ok {
+		g.X = x
+		g.Rotation = rotation
 		g.lastRotate = true
 		g.resetLock()
-		return
 	}
-	for _, dx := range []int{-1, 1, -2, 2} {
-		if !g.collides(g.X+dx, g.Y, newRot) {
-			g.X += dx
-			g.Rotation = newRot
-			g.lastRotate = true
-			g.resetLock()
-			return
+}
+
+func (g *Game) kick(x, y, rotation, dir int) (int, int, bool) {
+	newRot := (rotation + dir + 4) % 4
+	for _, dx := range []int{0, -1, 1, -2, 2} {
+		if !g.collides(x+dx, y, newRot) {
+			return x + dx, newRot, true
 		}
 	}
+	return x, rotation, false
 }
 
 func (g *Game) Hold() {
 	if g.Over || g.Paused || !g.CanHold || g.hasPendingLineClear() {
 		return
 	}
+	g.Stats.Keys++
+	g.logInput(inputHold)
 	if !g.HasHold {
 		g.HoldKind = g.Current
 		g.HasHold = true
@@ -185,35 +225,136 @@ func (g *Game) Hold() {
 	g.CanHold = false
 }
 
-func (g *Game) Step() LockResult {
+// Place locks the current piece at x, y. spin claims the piece got there
+// with a rotation, which is what T-spin scoring looks at.
+func (g *Game) Place(x, y, rotation int, spin bool) (LockResult, bool) {
 	if g.Over || g.Paused || g.hasPendingLineClear() {
-		return LockResult{}
+		return LockResult{}, false
 	}
-	if !g.collides(g.X, g.Y+1, g.Rotation) {
-		g.Y++
-		g.resetLock()
-		return LockResult{}
+	if rotation < 0 || rotation > 3 || g.collides(x, y, rotation) || !g.collides(x, y+1, rotation) {
+		return LockResult{}, false
 	}
-	if g.lockStart.IsZero() {
-		g.lockStart = time.Now()
+	if !g.reaches(g.Current, Placement{X: x, Y: y, Rotation: rotation, Spin: spin}) {
+		return LockResult{}, false
+	}
+	g.logPlace(x, y, rotation, spin)
+	g.X = x
+	g.Y = y
+	g.Rotation = rotation
+	g.lastRotate = spin
+	result := g.lockAndSpawn()
+	result.Locked = true
+	return result, true
+}
+
+func (g *Game) AddGarbage(lines int) {
+	if lines > 0 {
+		g.Garbage += lines
+		g.logGarbage(lines)
+	}
+}
+
+func (g *Game) OffsetGarbage(attack int) int {
+	if attack <= 0 || g.Garbage == 0 {
+		return attack
+	}
+	g.logInput(inputOffset)
+	if attack >= g.Garbage {
+		attack -= g.Garbage
+		g.Garbage = 0
+		return attack
+	}
+	g.Garbage -= attack
+	return 0
+}
+
+func (g *Game) applyGarbage() {
+	lines := g.Garbage
+	g.Garbage = 0
+	if lines <= 0 {
+		return
+	}
+	if lines > boardHeight {
+		lines = boardHeight
+	}
+	for y := 0; y < lines; y++ {
+		for x := 0; x < boardWidth; x++ {
+			if g.Board[y][x] != 0 {
+				g.Over = true
+			}
+		}
+	}
+	for y := 0; y < boardHeight-lines; y++ {
+		copy(g.Board[y], g.Board[y+lines])
+	}
+	hole := g.rng.Intn(boardWidth)
+	for y := boardHeight - lines; y < boardHeight; y++ {
+		for x := 0; x < boardWidth; x++ {
+			g.Board[y][x] = garbageCell
+		}
+		g.Board[y][hole] = 0
+	}
+}
+
+func attackForClear(result LockResult) int {
+	attack := 0
+	if result.TSpin {
+		attack = result.Cleared * 2
+	} else {
+		switch result.Cleared {
+		case 2:
+			attack = 1
+		case 3:
+			attack = 2
+		case 4:
+			attack = 4
+		}
+	}
+	if result.Cleared > 0 && result.BackToBack > 1 {
+		attack++
+	}
+	if result.Combo > 1 {
+		attack += (result.Combo - 1) / 2
+	}
+	return attack
+}
+
+func (g *Game) Step() LockResult {
+	if g.Over || g.Paused || g.hasPendingLineClear() {
 		return LockResult{}
 	}
-	if time.Since(g.lockStart) < lockDelay {
+	if !g.collides(g.X, g.Y+1, g.Rotation) || g.lockStart.IsZero() || time.Since(g.lockStart) < lockDelay {
+		g.logInput(inputGravity)
+		g.fall()
 		return LockResult{}
 	}
+	g.logInput(inputLock)
 	result := g.lockAndSpawn()
 	result.Locked = true
 	return result
 }
 
+func (g *Game) fall() {
+	if !g.collides(g.X, g.Y+1, g.Rotation) {
+		g.Y++
+		g.resetLock()
+	} else if g.lockStart.IsZero() {
+		g.lockStart = time.Now()
+	}
+}
+
 func (g *Game) lockAndSpawn() LockResult {
 	result := LockResult{}
+	kind := g.Current
 	result.TSpin = g.isTSpin()
+	result.Finesse = g.finesseFault()
+	g.Finesse += result.Finesse
 	g.lockPiece()
 	rows := g.fullRows()
 	cleared := len(rows)
 	result.Cleared = cleared
 	result.ClearedRows = rows
+	result.PerfectClear = cleared > 0 && g.isPerfectClear(rows)
 	if result.TSpin {
 		scoreTable := []int{400, 800, 1200, 1600}
 		if cleared >= 0 && cleared < len(scoreTable) {
@@ -231,7 +372,10 @@ func (g *Game) lockAndSpawn() LockResult {
 		g.Level = g.Lines / 10
 		g.pendingRows = append([]int{}, rows...)
 	} else {
-		g.spawnNext()
+		g.applyGarbage()
+		if !g.Over {
+			g.spawnNext()
+		}
 	}
 	if cleared > 0 {
 		g.Combo++
@@ -247,6 +391,8 @@ func (g *Game) lockAndSpawn() LockResult {
 		g.Combo = 0
 		g.BackToBack = 0
 	}
+	result.Attack = attackForClear(result)
+	g.Stats.record(kind, result)
 	g.resetLock()
 	g.lastRotate = false
 	return result
@@ -269,9 +415,10 @@ func (g *Game) spawnNext() {
 }
 
 func (g *Game) spawn() {
-	g.X = 3
+	g.X = spawnX
 	g.Y = 0
-	g.Rotation = 0
+	g.Rotation = spawnRotation
+	g.pieceInputs = 0
 	g.CanHold = true
 	g.resetLock()
 	if g.collides(g.X, g.Y, g.Rotation) {
@@ -356,11 +503,30 @@ func (g *Game) ResolveLineClear() {
 	if !g.hasPendingLineClear() {
 		return
 	}
+	g.logInput(inputResolve)
 	g.clearRows(g.pendingRows)
 	g.pendingRows = nil
 	g.spawnNext()
 }
 
+func (g *Game) isPerfectClear(rows []int) bool {
+	clearing := make(map[int]struct{}, len(rows))
+	for _, row := range rows {
+		clearing[row] = struct{}{}
+	}
+	for y := 0; y < boardHeight; y++ {
+		if _, ok := clearing[y]; ok {
+			continue
+		}
+		for x := 0; x < boardWidth; x++ {
+			if g.Board[y][x] != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (g *Game) hasPendingLineClear() bool {
 	return len(g.pendingRows) > 0
 }
@@ -435,6 +601,8 @@ func (g *Game) GhostY() int {
 	return y
 }
 
+var pieceNames = [7]string{"I", "O", "T", "S", "Z", "J", "L"}
+
 var pieceRotations = [7][4][]Point{
 	// I
 	{
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type HistoryRecord struct {
+	ID         string     `json:"id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Score      int        `json:"score"`
+	Lines      int        `json:"lines"`
+	Level      int        `json:"level"`
+	When       string     `json:"when"`
+	Mode       string     `json:"mode"`
+	RuleSet    string     `json:"rule_set"`
+	Seed       int64      `json:"seed"`
+	DurationMs int64      `json:"duration_ms"`
+	Stats      *GameStats `json:"stats,omitempty"`
+	ReplayPath string     `json:"replay_path,omitempty"`
+	Version    string     `json:"version,omitempty"`
+	Platform   string     `json:"platform,omitempty"`
+	Board      string     `json:"board,omitempty"`
+	// Replay is only held in memory; on disk it lives at ReplayPath, so
+	// listing scores does not parse every game's input log.
+	Replay string `json:"-"`
+}
+
+// historyLine reads records written before replays moved to their own files.
+type historyLine struct {
+	HistoryRecord
+	Replay string `json:"replay,omitempty"`
+}
+
+func (r HistoryRecord) ScoreEntry() ScoreEntry {
+	entry := ScoreEntry{
+		ID:         r.ID,
+		Name:       r.Name,
+		Score:      r.Score,
+		Lines:      r.Lines,
+		Level:      r.Level,
+		When:       r.When,
+		Mode:       r.Mode,
+		Stats:      r.Stats,
+		Seed:       r.Seed,
+		RuleSet:    r.RuleSet,
+		Replay:     r.Replay,
+		ReplayPath: r.ReplayPath,
+		DurationMs: r.DurationMs,
+		Version:    r.Version,
+		Platform:   r.Platform,
+		Board:      r.Board,
+	}
+	if r.Stats != nil {
+		entry.Pieces = r.Stats.Pieces
+		entry.MaxCombo = r.Stats.MaxCombo
+	}
+	return entry
+}
+
+func appendHistory(record HistoryRecord) error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if err := storeReplay(&record); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func loadHistory() ([]HistoryRecord, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return []HistoryRecord{}, nil
+	}
+	defer file.Close()
+	records := []HistoryRecord{}
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var record historyLine
+			if err := json.Unmarshal(line, &record); err != nil {
+				DebugLogf("history parse error: %v", err)
+			} else {
+				record.HistoryRecord.Replay = record.Replay
+				records = append(records, record.HistoryRecord)
+			}
+		}
+		if readErr == io.EOF {
+			return records, nil
+		}
+		if readErr != nil {
+			return records, readErr
+		}
+	}
+}
+
+func historyScores(records []HistoryRecord) []ScoreEntry {
+	scores := []ScoreEntry{}
+	for _, record := range records {
+		if record.Name != "" {
+			scores = append(scores, record.ScoreEntry())
+		}
+	}
+	sortScores(scores)
+	return scores
+}
+
+func pruneHistory(keep func(HistoryRecord) bool) (int, error) {
+	path, err := historyPath()
+	if err != nil {
+		return 0, err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	records, err := loadHistory()
+	if err != nil {
+		return 0, err
+	}
+	var b bytes.Buffer
+	removed := 0
+	moved := false
+	for _, record := range records {
+		if !keep(record) {
+			removed++
+			if replay, err := replayFile(record.ReplayPath); err == nil {
+				_ = os.Remove(replay)
+			}
+			continue
+		}
+		if record.Replay != "" && record.ReplayPath == "" {
+			if err := storeReplay(&record); err != nil {
+				return 0, err
+			}
+			moved = true
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return 0, err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	if removed == 0 && !moved {
+		return 0, nil
+	}
+	return removed, replaceFile(path, b.Bytes())
+}
+
+// moveInlineReplays rewrites history written by older versions so every
+// replay lives in its own file.
+func moveInlineReplays(records []HistoryRecord) error {
+	for _, record := range records {
+		if record.Replay != "" && record.ReplayPath == "" {
+			_, err := pruneHistory(func(HistoryRecord) bool { return true })
+			return err
+		}
+	}
+	return nil
+}
+
+// storeReplay writes record.Replay to replays/ in the data dir and points
+// ReplayPath at it.
+func storeReplay(record *HistoryRecord) error {
+	if record.Replay == "" || record.ReplayPath != "" {
+		return nil
+	}
+	name := record.ID
+	if !validScoreID(name) {
+		name = newScoreID()
+	}
+	relative := "replays/" + name + ".replay"
+	path, err := replayFile(relative)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := replaceFile(path, []byte(record.Replay)); err != nil {
+		return err
+	}
+	record.ReplayPath = relative
+	return nil
+}
+
+// replayFile resolves a record's ReplayPath. Only the file name is used, so
+// an edited history line cannot point outside the replays dir.
+func replayFile(relative string) (string, error) {
+	name := filepath.Base(filepath.FromSlash(relative))
+	if relative == "" || name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", os.ErrNotExist
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "replays", name), nil
+}
+
+func loadReplay(relative string) (string, error) {
+	path, err := replayFile(relative)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// withReplays fills in the replays that history keeps in separate files.
+func withReplays(scores []ScoreEntry) []ScoreEntry {
+	for i := range scores {
+		if scores[i].Replay != "" || scores[i].ReplayPath == "" {
+			continue
+		}
+		replay, err := loadReplay(scores[i].ReplayPath)
+		if err != nil {
+			DebugLogf("replay load error: %v", err)
+			continue
+		}
+		scores[i].Replay = replay
+	}
+	return scores
+}
+
+// importLegacyScores moves the scores.json written by older versions into
+// history, giving each score an ID, and renames the file so it is read once.
+func importLegacyScores() error {
+	legacyPath, err := scoresPath()
+	if err != nil {
+		return err
+	}
+	var legacy []ScoreEntry
+	if _, err := readJSONFile(legacyPath, &legacy); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	records, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	known := newScoreIndex(historyScores(records))
+	var b bytes.Buffer
+	imported := 0
+	for _, entry := range legacy {
+		if known.has(entry) {
+			continue
+		}
+		known.add(entry)
+		if entry.ID == "" {
+			entry.ID = newScoreID()
+		}
+		record := legacyHistoryRecord(entry)
+		if err := storeReplay(&record); err != nil {
+			return err
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+		imported++
+	}
+	if imported > 0 {
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(b.Bytes()); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	DebugLogf("imported %d of %d legacy scores into history", imported, len(legacy))
+	return os.Rename(legacyPath, legacyPath+".imported")
+}
+
+func legacyHistoryRecord(entry ScoreEntry) HistoryRecord {
+	return HistoryRecord{
+		ID:         entry.ID,
+		Name:       entry.Name,
+		Score:      entry.Score,
+		Lines:      entry.Lines,
+		Level:      entry.Level,
+		When:       entry.When,
+		Mode:       entry.Mode,
+		RuleSet:    entry.RuleSet,
+		Seed:       entry.Seed,
+		DurationMs: entry.DurationMs,
+		Stats:      entry.Stats,
+		Replay:     entry.Replay,
+		Version:    entry.Version,
+		Platform:   entry.Platform,
+		Board:      entry.Board,
+	}
+}
+
+func historyPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+func (m *Model) recordHistory(name string) HistoryRecord {
+	stats := m.game.LiveStats()
+	record := HistoryRecord{
+		ID:         newScoreID(),
+		Name:       name,
+		Score:      m.game.Score,
+		Lines:      m.game.Lines,
+		Level:      m.game.Level,
+		When:       time.Now().Format(time.RFC3339),
+		Mode:       m.gameMode(),
+		RuleSet:    ruleSet,
+		Seed:       m.game.Seed,
+		DurationMs: stats.DurationMs,
+		Stats:      &stats,
+		Replay:     m.game.ReplayLog(),
+		Version:    version,
+		Platform:   platform(),
+		Board:      boardSize(),
+	}
+	if m.historyDone {
+		return record
+	}
+	m.historyDone = true
+	if err := appendHistory(record); err != nil {
+		DebugLogf("history append error: %v", err)
+	}
+	if err := recordCareerEntry(careerEntry(record)); err != nil {
+		DebugLogf("career record error: %v", err)
+	}
+	return record
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func useTempDataDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("APPDATA", dir)
+	SetProfile("")
+	SetPortable(false)
+}
+
+func TestHistoryKeepsReplaysInFiles(t *testing.T) {
+	useTempDataDir(t)
+	long := HistoryRecord{ID: newScoreID(), Name: "long", Score: 10, Replay: strings.Repeat("LRXZ", 1<<19)}
+	short := HistoryRecord{ID: newScoreID(), Name: "short", Score: 5}
+	for _, record := range []HistoryRecord{long, short} {
+		if err := appendHistory(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path, err := historyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() > 4096 {
+		t.Fatalf("history.jsonl holds the replay: %v", err)
+	}
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Replay != "" || records[1].ReplayPath != "" {
+		t.Fatalf("loaded %+v", records)
+	}
+	if replay, err := loadReplay(records[0].ReplayPath); err != nil || replay != long.Replay {
+		t.Fatalf("replay file: %d bytes, %v", len(replay), err)
+	}
+	scores := withReplays(historyScores(records))
+	if scores[0].Replay != long.Replay {
+		t.Fatal("withReplays did not load the replay")
+	}
+}
+
+func TestMoveInlineReplays(t *testing.T) {
+	useTempDataDir(t)
+	path, err := historyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := `{"id":"` + newScoreID() + `","name":"old","score":7,"replay":"` + strings.Repeat("LR", 1<<20) + `"}` + "\n"
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scores, err := loadAllScores()
+	if err != nil || len(scores) != 1 || len(scores[0].Replay) != 1<<21 {
+		t.Fatalf("first load: %d scores, %v", len(scores), err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"replay"`) || !strings.Contains(string(data), `"replay_path"`) {
+		t.Fatalf("history was not rewritten: %.200s", data)
+	}
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay, err := loadReplay(records[0].ReplayPath); err != nil || len(replay) != 1<<21 {
+		t.Fatalf("moved replay: %d bytes, %v", len(replay), err)
+	}
+	if _, err := pruneHistory(func(HistoryRecord) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	if file, _ := replayFile(records[0].ReplayPath); fileExists(file) {
+		t.Fatal("pruning kept the replay file")
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestReplayFileStaysInReplays(t *testing.T) {
+	useTempDataDir(t)
+	dir, err := dataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := replayFile("../../../etc/passwd")
+	if err != nil || path != filepath.Join(dir, "replays", "passwd") {
+		t.Fatalf("replayFile = %q, %v", path, err)
+	}
+}
+
+func metadataRecord() HistoryRecord {
+	return HistoryRecord{
+		ID:         newScoreID(),
+		Name:       "ann",
+		Score:      4200,
+		Lines:      12,
+		Level:      1,
+		When:       "2024-05-06T07:08:09Z",
+		Mode:       gameModeBattle,
+		RuleSet:    ruleSet,
+		Seed:       1717171717171717171,
+		Stats:      &GameStats{Pieces: 40, MaxCombo: 3, DurationMs: 90500},
+		DurationMs: 90500,
+		Version:    "1.2.0",
+		Platform:   "linux/amd64",
+		Board:      "10x20",
+	}
+}
+
+func sameMetadata(a, b ScoreEntry) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.Score == b.Score && a.Lines == b.Lines &&
+		a.Level == b.Level && a.Mode == b.Mode && a.RuleSet == b.RuleSet && a.Seed == b.Seed &&
+		a.DurationMs == b.DurationMs && a.Pieces == b.Pieces && a.MaxCombo == b.MaxCombo &&
+		a.Version == b.Version && a.Platform == b.Platform && a.Board == b.Board
+}
+
+func TestScoreMetadataRoundTrip(t *testing.T) {
+	entry := metadataRecord().ScoreEntry()
+	if entry.Pieces != 40 || entry.MaxCombo != 3 {
+		t.Fatalf("ScoreEntry dropped stats: %+v", entry)
+	}
+	upload := newUploadScore(entry)
+	if upload.PlayedAt != entry.When {
+		t.Fatalf("played_at %q, want %q", upload.PlayedAt, entry.When)
+	}
+	api := apiScore{
+		ID: upload.ID, Name: upload.Name, Score: upload.Score, Lines: upload.Lines, Level: upload.Level,
+		Mode: upload.Mode, CreatedAt: upload.PlayedAt, Seed: upload.Seed, RuleSet: upload.RuleSet,
+		DurationMs: upload.DurationMs, Pieces: upload.Pieces, MaxCombo: upload.MaxCombo,
+		Version: upload.Version, Platform: upload.Platform, Board: upload.Board,
+	}
+	if back := api.ToScoreEntry(); !sameMetadata(entry, back) || back.When != entry.When {
+		t.Fatalf("round trip changed the score:\n%+v\n%+v", entry, back)
+	}
+}
+
+func TestImportLegacyScores(t *testing.T) {
+	useTempDataDir(t)
+	kept := HistoryRecord{ID: newScoreID(), Name: "ann", Score: 900, When: "2024-01-01 10:00"}
+	if err := appendHistory(kept); err != nil {
+		t.Fatal(err)
+	}
+	legacy := []ScoreEntry{
+		kept.ScoreEntry(),
+		{Name: "bob", Score: 500, When: "2024-01-02 10:00"},
+		{Name: "cat", Score: 500, When: "2024-01-02 10:00"},
+	}
+	path, err := scoresPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		scores, err := loadAllScores()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(scores) != 3 {
+			t.Fatalf("loaded %d scores, want 3", len(scores))
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("scores.json was not retired: %v", err)
+	}
+	if _, err := os.Stat(path + ".imported"); err != nil {
+		t.Fatal(err)
+	}
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]bool{}
+	for _, record := range records {
+		if record.ID == "" || ids[record.ID] {
+			t.Fatalf("imported record %+v has a missing or repeated ID", record)
+		}
+		ids[record.ID] = true
+	}
+	if len(records) != 3 {
+		t.Fatalf("history has %d records, want 3", len(records))
+	}
+}
+
+func TestNameEntrySavesLocallyWithSync(t *testing.T) {
+	useTempDataDir(t)
+	backend := &memoryBackend{}
+	backend.SetError(errors.New("offline"))
+	m := Model{game: NewGameWithSeed(1), sync: &ScoreSync{enabled: true, backend: backend}, nameInput: "ann"}
+	m.game.Score = 700
+	m.updateNameEntry(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.scores) != 1 || m.scores[0].Name != "ann" {
+		t.Fatalf("scores screen shows %+v", m.scores)
+	}
+	scores, err := loadScores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 || scores[0].Score != 700 {
+		t.Fatalf("local scores %+v", scores)
+	}
+}
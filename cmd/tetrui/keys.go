@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type gameAction int
+
+const (
+	actionNone gameAction = iota
+	actionLeft
+	actionRight
+	actionSoftDrop
+	actionHardDrop
+	actionRotateCW
+	actionRotateCCW
+	actionHold
+	actionPause
+)
+
+type keyBinding struct {
+	action  gameAction
+	setting string
+	keys    func(*Config) *string
+}
+
+var keyBindings = []keyBinding{
+	{actionLeft, "key_left", func(c *Config) *string { return &c.KeyLeft }},
+	{actionRight, "key_right", func(c *Config) *string { return &c.KeyRight }},
+	{actionSoftDrop, "key_soft_drop", func(c *Config) *string { return &c.KeySoftDrop }},
+	{actionHardDrop, "key_hard_drop", func(c *Config) *string { return &c.KeyHardDrop }},
+	{actionRotateCW, "key_rotate_cw", func(c *Config) *string { return &c.KeyRotateCW }},
+	{actionRotateCCW, "key_rotate_ccw", func(c *Config) *string { return &c.KeyRotateCCW }},
+	{actionHold, "key_hold", func(c *Config) *string { return &c.KeyHold }},
+	{actionPause, "key_pause", func(c *Config) *string { return &c.KeyPause }},
+}
+
+// Q and Esc always go back to the menu so a bad binding cannot trap anyone.
+var reservedKeys = []string{"q", "esc"}
+
+func splitKeys(text string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(text, ",") {
+		key = strings.TrimSpace(key)
+		if strings.EqualFold(key, "space") {
+			key = " "
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func validateKeys(config *Config) []string {
+	defaults := defaultConfig()
+	issues := []string{}
+	bound := map[string]string{}
+	for _, binding := range keyBindings {
+		keys := binding.keys(config)
+		fallback := *binding.keys(&defaults)
+		reset := len(splitKeys(*keys)) == 0
+		if reset {
+			issues = append(issues, fmt.Sprintf("%s: no keys, using %s", binding.setting, fallback))
+		}
+		for _, key := range splitKeys(*keys) {
+			other, taken := bound[key]
+			if slices.Contains(reservedKeys, key) {
+				other, taken = "the menu", true
+			}
+			if taken {
+				issues = append(issues, fmt.Sprintf("%s: %s is already used by %s, using %s", binding.setting, keyLabel(key), other, fallback))
+				reset = true
+				break
+			}
+		}
+		if reset {
+			*keys = fallback
+		}
+		for _, key := range splitKeys(*keys) {
+			bound[key] = binding.setting
+		}
+	}
+	return issues
+}
+
+func (m *Model) keyAction(msg tea.KeyMsg) gameAction {
+	key := msg.String()
+	for _, binding := range keyBindings {
+		if slices.Contains(splitKeys(*binding.keys(&m.config)), key) {
+			return binding.action
+		}
+	}
+	return actionNone
+}
+
+func keyLabel(key string) string {
+	if key == " " {
+		return "Space"
+	}
+	if len(key) == 1 {
+		return strings.ToUpper(key)
+	}
+	return strings.ToUpper(key[:1]) + key[1:]
+}
+
+func keysLabel(text string) string {
+	labels := []string{}
+	for _, key := range splitKeys(text) {
+		labels = append(labels, keyLabel(key))
+	}
+	return strings.Join(labels, "/")
+}
+
+func (c Config) coachHoldLabel() string {
+	return fmt.Sprintf("Coach: hold (%s)", keysLabel(c.KeyHold))
+}
+
+func (c Config) keyHelp() []string {
+	return []string{
+		fmt.Sprintf("%s, %s: move", keysLabel(c.KeyLeft), keysLabel(c.KeyRight)),
+		keysLabel(c.KeySoftDrop) + ": soft drop",
+		fmt.Sprintf("%s, %s: rotate", keysLabel(c.KeyRotateCW), keysLabel(c.KeyRotateCCW)),
+		keysLabel(c.KeyHardDrop) + ": hard drop",
+		keysLabel(c.KeyHold) + ": hold",
+		keysLabel(c.KeyPause) + ": pause",
+		"Q: menu",
+	}
+}
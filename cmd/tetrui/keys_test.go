@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func runeKey(key string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+}
+
+func TestKeyActionDefaults(t *testing.T) {
+	m := Model{config: defaultConfig()}
+	tests := []struct {
+		msg  tea.KeyMsg
+		want gameAction
+	}{
+		{tea.KeyMsg{Type: tea.KeyLeft}, actionLeft},
+		{runeKey("l"), actionRight},
+		{tea.KeyMsg{Type: tea.KeyDown}, actionSoftDrop},
+		{tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, actionHardDrop},
+		{runeKey("x"), actionRotateCW},
+		{runeKey("z"), actionRotateCCW},
+		{runeKey("c"), actionHold},
+		{runeKey("p"), actionPause},
+		{runeKey("q"), actionNone},
+	}
+	for _, test := range tests {
+		if got := m.keyAction(test.msg); got != test.want {
+			t.Errorf("%q: got action %d, want %d", test.msg.String(), got, test.want)
+		}
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	config := defaultConfig()
+	config.KeyHold = "v, shift+c"
+	config.KeyPause = "q"
+	config.KeyRotateCCW = "x"
+	config.KeySoftDrop = ""
+	issues := validateKeys(&config)
+	if len(issues) != 3 {
+		t.Fatalf("issues %v", issues)
+	}
+	defaults := defaultConfig()
+	if config.KeyHold != "v, shift+c" || config.KeyPause != defaults.KeyPause ||
+		config.KeyRotateCCW != defaults.KeyRotateCCW || config.KeySoftDrop != defaults.KeySoftDrop {
+		t.Fatalf("bindings after validation: %+v", config)
+	}
+	m := Model{config: config}
+	if m.keyAction(runeKey("v")) != actionHold || m.keyAction(runeKey("c")) != actionNone {
+		t.Fatal("rebound hold key not used")
+	}
+}
+
+func TestProfileKeyBindings(t *testing.T) {
+	useTempDataDir(t)
+	SetProfile("alice")
+	config := defaultConfig()
+	config.KeyHold = "v"
+	if err := saveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	SetProfile("")
+	t.Cleanup(func() { SetProfile("") })
+
+	m := Model{config: defaultConfig()}
+	m.applyProfile("alice")
+	if m.keyAction(runeKey("v")) != actionHold {
+		t.Fatal("alice's hold key was not applied")
+	}
+	m.applyProfile("")
+	if m.keyAction(runeKey("c")) != actionHold {
+		t.Fatal("default profile kept alice's keys")
+	}
+}
+
+func TestCoachHoldLabel(t *testing.T) {
+	config := defaultConfig()
+	if got := config.coachHoldLabel(); got != "Coach: hold (C)" {
+		t.Errorf("default label = %q", got)
+	}
+	config.KeyHold = "shift,v"
+	if got := config.coachHoldLabel(); got != "Coach: hold (Shift/V)" {
+		t.Errorf("rebound label = %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	liveBaseDelay = time.Second
+	liveMaxDelay  = time.Minute
+	livePing      = 15 * time.Second
+	liveKeep      = 100
+)
+
+type liveScoreMsg struct {
+	feed  *LiveFeed
+	entry ScoreEntry
+}
+
+type liveStatusMsg struct {
+	feed      *LiveFeed
+	connected bool
+	err       error
+	retry     time.Duration
+}
+
+type LiveFeed struct {
+	url    string
+	apiKey string
+	client *http.Client
+	events chan tea.Msg
+	stop   chan struct{}
+	once   sync.Once
+}
+
+func (s *ScoreSync) Subscribe() *LiveFeed {
+	if !s.Enabled() || s.eventsURL == "" {
+		return nil
+	}
+	feed := &LiveFeed{
+		url:    s.eventsURL,
+		apiKey: s.apiKey,
+		client: &http.Client{},
+		events: make(chan tea.Msg),
+		stop:   make(chan struct{}),
+	}
+	DebugLogf("live feed start url=%s", feed.url)
+	go feed.run()
+	return feed
+}
+
+func (f *LiveFeed) Close() {
+	if f == nil {
+		return
+	}
+	f.once.Do(func() {
+		close(f.stop)
+	})
+}
+
+func (f *LiveFeed) run() {
+	delay := liveBaseDelay
+	for {
+		connected, err := f.stream()
+		select {
+		case <-f.stop:
+			return
+		default:
+		}
+		if connected {
+			delay = liveBaseDelay
+		}
+		DebugLogf("live feed disconnected, retry in %s: %v", delay, err)
+		if !f.send(liveStatusMsg{feed: f, err: err, retry: delay}) {
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-f.stop:
+			return
+		}
+		delay = min(delay*2, liveMaxDelay)
+	}
+}
+
+func (f *LiveFeed) send(msg tea.Msg) bool {
+	select {
+	case f.events <- msg:
+		return true
+	case <-f.stop:
+		return false
+	}
+}
+
+func (f *LiveFeed) stream() (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-f.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if f.apiKey != "" {
+		req.Header.Set("X-Api-Key", f.apiKey)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, errUnexpectedStatus(resp.StatusCode)
+	}
+	if !f.send(liveStatusMsg{feed: f, connected: true}) {
+		return true, nil
+	}
+	event := ""
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 && (event == "" || event == "score") {
+				var score apiScore
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &score); err != nil {
+					DebugLogf("live feed bad event: %v", err)
+				} else if !f.send(liveScoreMsg{feed: f, entry: score.ToScoreEntry()}) {
+					return true, nil
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, errors.New("stream closed")
+}
+
+func waitLiveCmd(feed *LiveFeed) tea.Cmd {
+	if feed == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case msg := <-feed.events:
+			return msg
+		case <-feed.stop:
+			return nil
+		}
+	}
+}
+
+func liveStatusText(msg liveStatusMsg) string {
+	if msg.connected {
+		return "Live"
+	}
+	return fmt.Sprintf("Live: reconnecting in %s", msg.retry)
+}
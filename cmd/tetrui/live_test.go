@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func nextLiveMsg(t *testing.T, feed *LiveFeed) tea.Msg {
+	t.Helper()
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- waitLiveCmd(feed)() }()
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no live feed message")
+		return nil
+	}
+}
+
+func TestLiveFeed(t *testing.T) {
+	server := newTestScoreServer(t, "k")
+	stub := httptest.NewServer(server.Handler())
+	defer stub.Close()
+	sync := NewScoreSync(Config{Sync: true, ScoreAPIURL: stub.URL, ScoreAPIKey: "k", ScoreEvents: stub.URL + "/events"})
+	feed := sync.Subscribe()
+	if feed == nil {
+		t.Fatal("no feed")
+	}
+	defer feed.Close()
+	if status, ok := nextLiveMsg(t, feed).(liveStatusMsg); !ok || !status.connected {
+		t.Fatalf("first message %+v", status)
+	}
+
+	if err := sync.UploadScore(ScoreEntry{ID: newScoreID(), Name: "ann", Score: 1200, Lines: 3}); err != nil {
+		t.Fatal(err)
+	}
+	live, ok := nextLiveMsg(t, feed).(liveScoreMsg)
+	if !ok || live.entry.Name != "ann" || live.entry.Score != 1200 {
+		t.Fatalf("live score %+v", live)
+	}
+	m := Model{sync: sync, live: feed}
+	next, _ := m.Update(live)
+	if scores := next.(Model).liveScores; len(scores) != 1 || scores[0].Name != "ann" {
+		t.Fatalf("live scores %+v", scores)
+	}
+
+	server.Close()
+	stub.Close()
+	var retries []time.Duration
+	for len(retries) < 2 {
+		status, ok := nextLiveMsg(t, feed).(liveStatusMsg)
+		if !ok || status.connected {
+			t.Fatalf("expected a disconnect, got %+v", status)
+		}
+		retries = append(retries, status.retry)
+	}
+	if retries[1] <= retries[0] {
+		t.Fatalf("retry did not grow: %v", retries)
+	}
+}
+
+func TestSubscribeNeedsSync(t *testing.T) {
+	config := Config{Sync: false, ScoreAPIURL: "http://127.0.0.1:1", ScoreEvents: "http://127.0.0.1:1/events"}
+	if feed := NewScoreSync(config).Subscribe(); feed != nil {
+		feed.Close()
+		t.Fatal("subscribed with sync disabled")
+	}
+	config.ScoreEvents = ""
+	config.Sync = true
+	if feed := NewScoreSync(config).Subscribe(); feed != nil {
+		feed.Close()
+		t.Fatal("subscribed without an events URL")
+	}
+}
+
+func TestLiveScoresCapped(t *testing.T) {
+	feed := &LiveFeed{events: make(chan tea.Msg), stop: make(chan struct{})}
+	defer feed.Close()
+	m := Model{sync: &ScoreSync{enabled: true, backend: &memoryBackend{}}, live: feed}
+	for i := range liveKeep + 20 {
+		next, _ := m.Update(liveScoreMsg{feed: feed, entry: ScoreEntry{ID: fmt.Sprint(i), Name: "bot", Score: i}})
+		m = next.(Model)
+	}
+	if len(m.liveScores) != liveKeep || m.liveScores[0].Score != liveKeep+19 {
+		t.Fatalf("kept %d live scores, best %d", len(m.liveScores), m.liveScores[0].Score)
+	}
+}
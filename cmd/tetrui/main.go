@@ -2,20 +2,133 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "bot":
+			os.Exit(runBot(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "scores":
+			os.Exit(runScores(os.Args[2:]))
+		case "score-server":
+			os.Exit(runScoreServer(os.Args[2:]))
+		}
+	}
 	debug := flag.Bool("debug", false, "enable debug logging")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	spectate := flag.String("spectate", "", "publish live games for `tetrui watch` on this address (e.g. :7777)")
+	botCommand := flag.String("bot", "", "run an external TBP bot command and watch it play")
+	botPPS := flag.Float64("bot-pps", 2, "pieces per second for --bot")
+	profile := flag.String("profile", "", "use a named profile with its own config, scores and history")
+	configFile := flag.String("config", "", "read and write settings from this config file")
+	portable := flag.Bool("portable", false, "keep all data in "+portableDirName+" next to the binary")
+	flag.String("theme", "", "theme for this session")
+	flag.Int("volume", 0, "volume percent for this session")
+	flag.Int("scale", 0, "game scale for this session")
+	flag.Bool("no-sound", false, "disable sound effects for this session")
+	flag.Bool("no-music", false, "disable music for this session")
+	flag.Bool("no-sync", false, "disable score sync for this session")
+	var settings settingFlags
+	flag.Var(&settings, "set", "override a config setting for this session, as key=value (repeatable)")
 	flag.Parse()
+	if *showVersion {
+		fmt.Printf("tetrui %s %s\n", version, platform())
+		return
+	}
 	EnableDebugLogging(*debug)
 	DebugLogf("tetrui start debug=%v", *debug)
-	loadEmbeddedEnv()
-	program := tea.NewProgram(NewModel(), tea.WithAltScreen())
+	SetPortable(*portable)
+	SetConfigFile(*configFile)
+	if err := applyConfigFlags(settings); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if *botCommand != "" {
+		os.Exit(runTBP(*botCommand, *botPPS))
+	}
+	if *profile != "" {
+		if !validProfileName(*profile) {
+			fmt.Fprintf(os.Stderr, "profile: %q must be 1-%d letters, digits, - or _\n", *profile, profileNameLimit)
+			os.Exit(1)
+		}
+		SetProfile(*profile)
+	}
+	pickProfile := false
+	if *profile == "" {
+		name, chosen := lastProfile()
+		SetProfile(name)
+		pickProfile = !chosen
+	}
+	model := NewModel()
+	if pickProfile {
+		model.openProfiles()
+	}
+	if *spectate != "" {
+		hub, err := StartSpectatorHub(*spectate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "spectate: %v\n", err)
+			os.Exit(1)
+		}
+		defer hub.Close()
+		model.spectate = hub
+	}
+	program := tea.NewProgram(model, tea.WithAltScreen())
 	if _, err := program.Run(); err != nil {
 		DebugLogf("program error: %v", err)
 		os.Exit(1)
 	}
 }
+
+type settingFlags []string
+
+func (s *settingFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *settingFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func applyConfigFlags(settings []string) error {
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "theme", "volume", "scale":
+			err = AddConfigOverride("--"+f.Name, f.Name, f.Value.String())
+		case "no-sound", "no-music", "no-sync":
+			if f.Value.String() == "true" {
+				err = AddConfigOverride("--"+f.Name, strings.TrimPrefix(f.Name, "no-"), "false")
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("--%s: %v", f.Name, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, setting := range settings {
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return fmt.Errorf("--set %s: expected key=value", setting)
+		}
+		if err := AddConfigOverride("--set "+key, key, value); err != nil {
+			return fmt.Errorf("--set %s: %v", key, err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,17 +18,25 @@ const (
 	screenScores
 	screenConfig
 	screenNameEntry
+	screenBattle
+	screenAttract
+	screenTrainer
+	screenResults
+	screenCareer
+	screenProfiles
 )
 
 type tickMsg struct{}
 type soundMsg struct{}
 type scoresLoadedMsg struct {
+	query  ScoreQuery
 	scores []ScoreEntry
 	err    error
 }
 
 type scoreUploadedMsg struct {
-	err error
+	entry ScoreEntry
+	err   error
 }
 
 type syncTickMsg struct{}
@@ -49,6 +59,8 @@ type Model struct {
 	configIndex  int
 	themeIndex   int
 	scoresOffset int
+	scoresIndex  int
+	scoreDetail  bool
 	config       Config
 	scores       []ScoreEntry
 	game         Game
@@ -74,19 +86,66 @@ type Model struct {
 	hardDropDest []Point
 	hardDropFrom time.Time
 	hardDropTil  time.Time
+	spectate     *SpectatorHub
+	battle       Battle
+	battleIndex  int
+	lastInputAt  time.Time
+	attractFrom  time.Time
+	attractTil   time.Time
+	attractGoal  Placement
+	attractAimed bool
+	attractPath  []pieceMove
+	coachHint    []Point
+	coachHold    bool
+	coachSeq     int
+	trainerGoal  []Point
+	trainerNeed  int
+	trainerRun   int
+	trainerMiss  int
+	trainerNote  string
+	resultsIndex int
+	resultsRank  int
+	resultsTotal int
+	career       []PlayerStats
+	careerIndex  int
+	historyDone  bool
+	profiles     []string
+	profileIndex int
+	profileNew   bool
+	profileInput string
+	configIssues []string
+	outboxCount  int
+	remoteScores []ScoreEntry
+	remoteDone   bool
+	scoresTab    int
+	scoresWindow int
+	scoresMode   int
+	scoresSearch string
+	searching    bool
+	searchInput  string
+	live         *LiveFeed
+	liveStatus   string
+	liveScores   []ScoreEntry
 }
 
 func NewModel() Model {
-	config, _ := loadConfig()
+	config, issues, err := loadConfig()
+	if err != nil {
+		DebugLogf("config load error: %v", err)
+	}
 	index := themeIndexByName(config.Theme)
 	if index < 0 {
 		index = 0
 		config.Theme = themes[index].Name
 	}
-	sync := NewScoreSyncFromEnv(config.Sync)
-	scores := []ScoreEntry{}
-	if sync == nil || !sync.Enabled() {
-		scores, _ = loadScores()
+	sync := NewScoreSync(config)
+	scores, err := loadScores()
+	if err != nil {
+		DebugLogf("scores load error: %v", err)
+	}
+	tab := scoresTabLocal
+	if sync.Enabled() {
+		tab = scoresTabMerged
 	}
 	ctx, sampleRate, err := initAudioContext()
 	if err != nil {
@@ -95,19 +154,24 @@ func NewModel() Model {
 	sound := NewSoundEngine(ctx, sampleRate, config.Sound)
 	sound.SetVolume(volumeFromPercent(config.Volume))
 	return Model{
-		screen:     screenMenu,
-		config:     config,
-		scores:     scores,
-		themeIndex: index,
-		game:       NewGame(),
-		sound:      sound,
-		sync:       sync,
-		music:      NewMusicPlayer(ctx, sampleRate, volumeFromPercent(config.Volume), config.Music),
+		screen:       screenMenu,
+		config:       config,
+		configIssues: issues,
+		outboxCount:  outboxPending(),
+		scores:       scores,
+		scoresTab:    tab,
+		themeIndex:   index,
+		game:         NewGame(),
+		sound:        sound,
+		sync:         sync,
+		live:         sync.Subscribe(),
+		music:        NewMusicPlayer(ctx, sampleRate, volumeFromPercent(config.Volume), config.Music),
+		lastInputAt:  time.Now(),
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return m.syncMusicForScreen()
+	return tea.Batch(m.syncMusicForScreen(), idleTickCmd(), flushOutboxCmd(m.sync), waitLiveCmd(m.live))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -126,6 +190,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tickCmd(m.game.FallInterval())
 			}
 			result := m.game.Step()
+			m.publishSpectator()
 			if m.game.Over {
 				return m, m.startTopOutEffect()
 			}
@@ -137,6 +202,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if comboCmd := m.comboSoundCmd(result); comboCmd != nil {
 					cmds = append(cmds, comboCmd)
 				}
+				if coachCmd := m.requestCoach(); coachCmd != nil {
+					cmds = append(cmds, coachCmd)
+				}
 			}
 			if event, ok := soundEventForAction(result); ok && m.config.Sound {
 				cmds = append(cmds, playSound(m.sound, event))
@@ -147,6 +215,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tickCmd(m.game.FallInterval())
 		}
 		return m, nil
+	case opponentTickMsg:
+		cmd := m.updateOpponent(msg)
+		m.publishSpectator()
+		return m, cmd
+	case coachMsg:
+		m.applyCoach(msg)
+		return m, nil
+	case idleTickMsg:
+		return m, m.updateIdle()
+	case attractFadeTickMsg:
+		return m, m.updateAttractFade()
+	case attractTickMsg:
+		return m, m.updateAttract()
 	case soundMsg:
 		return m, nil
 	case syncTickMsg:
@@ -164,10 +245,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, lineClearTickCmd()
 		}
 		m.game.ResolveLineClear()
+		m.publishSpectator()
 		if m.game.Over {
 			return m, m.startTopOutEffect()
 		}
-		return m, nil
+		return m, m.requestCoach()
 	case countdownTickMsg:
 		if m.screen != screenGame || m.game.Paused || m.game.Over {
 			return m, nil
@@ -179,6 +261,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.startCount > 0 {
 			return m, countdownTickCmd()
 		}
+		m.game.StartClock()
 		if m.config.Sound {
 			return m, tea.Batch(playSound(m.sound, SoundMenuSelect), tickCmd(m.game.FallInterval()))
 		}
@@ -192,9 +275,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, topOutTickCmd()
 		}
 		m.topOutTil = time.Time{}
-		cmd := m.setScreen(screenNameEntry)
-		m.nameInput = ""
-		return m, cmd
+		return m, m.showResults()
 	case hardDropTraceTickMsg:
 		if m.screen != screenGame || m.hardDropTil.IsZero() {
 			return m, nil
@@ -205,6 +286,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case scoresLoadedMsg:
+		if msg.query != m.scoresQuery() {
+			return m, nil
+		}
 		if msg.err != nil {
 			DebugLogf("scores fetch error: %v", msg.err)
 			m.syncWarning = "Offline: scores not synced."
@@ -216,20 +300,63 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.syncWarning = ""
 		}
-		m.scores = msg.scores
+		m.remoteScores = append(m.remoteScores, msg.scores...)
+		m.remoteDone = len(msg.scores) < msg.query.Limit
 		m.syncLoading = false
 		return m, nil
 	case scoreUploadedMsg:
+		if msg.err != nil && scoreRejected(msg.err) {
+			m.syncWarning = "Score rejected by the server."
+			if status := errorStatus(msg.err); status == http.StatusUnauthorized || status == http.StatusForbidden {
+				m.syncWarning = "Score rejected: check score_api_key and score_secret."
+			}
+			m.syncLoading = false
+			return m, nil
+		}
 		if msg.err != nil {
 			DebugLogf("score upload error: %v", msg.err)
-			m.syncWarning = "Offline: scores not synced."
+			m.syncWarning = "Offline: score queued for upload."
 			m.syncLoading = false
+			count, err := queueUpload(msg.entry, msg.err)
+			if err != nil {
+				DebugLogf("outbox queue error: %v", err)
+				m.syncWarning = "Offline: scores not synced."
+				return m, nil
+			}
+			m.outboxCount = count
 			return m, nil
 		}
 		m.syncWarning = ""
 		m.syncLoading = false
 		return m, nil
+	case liveScoreMsg:
+		if msg.feed != m.live {
+			return m, nil
+		}
+		if m.sync.Enabled() {
+			m.liveScores = dedupeScores(m.liveScores, []ScoreEntry{msg.entry})
+			if len(m.liveScores) > liveKeep {
+				m.liveScores = m.liveScores[:liveKeep]
+			}
+		}
+		return m, waitLiveCmd(m.live)
+	case liveStatusMsg:
+		if msg.feed != m.live {
+			return m, nil
+		}
+		m.liveStatus = liveStatusText(msg)
+		return m, waitLiveCmd(m.live)
+	case outboxFlushedMsg:
+		m.outboxCount = msg.pending
+		if msg.sent > 0 && m.screen == screenScores && m.sync.Enabled() {
+			return m, m.reloadScores()
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.screen == screenAttract || !m.attractTil.IsZero() {
+			return m, m.stopAttract()
+		}
+		m.lastInputAt = time.Now()
 		switch msg.String() {
 		case "ctrl+=", "ctrl++":
 			m.adjustScale(1)
@@ -242,7 +369,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case screenMenu:
 			return m, m.updateMenu(msg)
 		case screenGame:
-			return m, m.updateGame(msg)
+			cmd := m.updateGame(msg)
+			m.publishSpectator()
+			return m, cmd
 		case screenThemes:
 			return m, m.updateThemes(msg)
 		case screenScores:
@@ -251,6 +380,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.updateConfig(msg)
 		case screenNameEntry:
 			return m, m.updateNameEntry(msg)
+		case screenBattle:
+			return m, m.updateBattleSelect(msg)
+		case screenTrainer:
+			return m, m.updateTrainer(msg)
+		case screenResults:
+			return m, m.updateResults(msg)
+		case screenCareer:
+			return m, m.updateCareer(msg)
+		case screenProfiles:
+			return m, m.updateProfiles(msg)
 		}
 	}
 	return m, nil
@@ -270,6 +409,18 @@ func (m Model) View() string {
 		return viewConfig(m)
 	case screenNameEntry:
 		return viewNameEntry(m)
+	case screenBattle:
+		return viewBattleSelect(m)
+	case screenAttract:
+		return viewAttract(m)
+	case screenTrainer:
+		return viewTrainer(m)
+	case screenResults:
+		return viewResults(m)
+	case screenCareer:
+		return viewCareer(m)
+	case screenProfiles:
+		return viewProfiles(m)
 	default:
 		return ""
 	}
@@ -434,23 +585,28 @@ func (m *Model) updateMenu(msg tea.KeyMsg) tea.Cmd {
 		}
 		switch m.menuIndex {
 		case 0:
-			m.game = NewGame()
-			m.startCount = 2
-			return tea.Batch(cmd, m.setScreen(screenGame), countdownTickCmd())
+			return tea.Batch(cmd, m.startGame())
 		case 1:
-			return tea.Batch(cmd, m.setScreen(screenThemes))
+			return tea.Batch(cmd, m.setScreen(screenBattle))
 		case 2:
-			m.scoresOffset = 0
+			m.startTrainer()
+			return tea.Batch(cmd, m.setScreen(screenTrainer))
+		case 3:
+			return tea.Batch(cmd, m.setScreen(screenThemes))
+		case 4:
+			m.scoresOffset, m.scoresIndex = 0, 0
 			if m.sync != nil && m.sync.Enabled() {
-				m.syncLoading = true
-				m.syncDots = 0
-				return tea.Batch(cmd, m.setScreen(screenScores), m.sync.FetchScoresCmd(), syncTickCmd())
+				return tea.Batch(cmd, m.setScreen(screenScores), m.reloadScores(), flushOutboxCmd(m.sync))
 			}
 			m.syncWarning = "Score sync is disabled."
 			return tea.Batch(cmd, m.setScreen(screenScores))
-		case 3:
+		case 5:
+			return tea.Batch(cmd, m.openCareer())
+		case 6:
 			return tea.Batch(cmd, m.setScreen(screenConfig))
-		case 4:
+		case 7:
+			return tea.Batch(cmd, m.openProfiles())
+		case 8:
 			return tea.Quit
 		}
 	case "q", "esc":
@@ -459,6 +615,13 @@ func (m *Model) updateMenu(msg tea.KeyMsg) tea.Cmd {
 	return cmd
 }
 
+func (m *Model) startGame() tea.Cmd {
+	m.game = NewGame()
+	m.battle = Battle{round: m.battle.round}
+	m.startCount = 2
+	return tea.Batch(m.setScreen(screenGame), countdownTickCmd(), m.requestCoach())
+}
+
 func (m *Model) updateGame(msg tea.KeyMsg) tea.Cmd {
 	if m.startCount > 0 {
 		switch msg.String() {
@@ -476,8 +639,8 @@ func (m *Model) updateGame(msg tea.KeyMsg) tea.Cmd {
 		return nil
 	}
 
-	switch msg.String() {
-	case "left", "h":
+	switch m.keyAction(msg) {
+	case actionLeft:
 		m.lastMoveDir = -1
 		m.lastMoveAt = time.Now()
 		if m.game.Move(-1) {
@@ -485,7 +648,7 @@ func (m *Model) updateGame(msg tea.KeyMsg) tea.Cmd {
 				return playSound(m.sound, SoundMove)
 			}
 		}
-	case "right", "l":
+	case actionRight:
 		m.lastMoveDir = 1
 		m.lastMoveAt = time.Now()
 		if m.game.Move(1) {
@@ -493,9 +656,9 @@ func (m *Model) updateGame(msg tea.KeyMsg) tea.Cmd {
 				return playSound(m.sound, SoundMove)
 			}
 		}
-	case "down", "j":
+	case actionSoftDrop:
 		m.game.SoftDrop()
-	case " ":
+	case actionHardDrop:
 		traceCmd := m.startHardDropTrace()
 		result := m.game.HardDrop()
 		if m.game.Over {
@@ -516,6 +679,9 @@ func (m *Model) updateGame(msg tea.KeyMsg) tea.Cmd {
 		if comboCmd := m.comboSoundCmd(result); comboCmd != nil {
 			cmds = append(cmds, comboCmd)
 		}
+		if coachCmd := m.requestCoach(); coachCmd != nil {
+			cmds = append(cmds, coachCmd)
+		}
 		if m.config.Sound {
 			if result.Cleared == 0 && !result.TSpin {
 				soundCmd := playSound(m.sound, SoundDrop)
@@ -537,24 +703,27 @@ func (m *Model) updateGame(msg tea.KeyMsg) tea.Cmd {
 		if len(cmds) > 0 {
 			return tea.Batch(cmds...)
 		}
-	case "up", "x":
+	case actionRotateCW:
 		m.game.Rotate(1)
 		m.applyMoveBuffer()
 		if m.config.Sound {
 			return playSound(m.sound, SoundRotate)
 		}
-	case "z":
+	case actionRotateCCW:
 		m.game.Rotate(-1)
 		m.applyMoveBuffer()
 		if m.config.Sound {
 			return playSound(m.sound, SoundRotate)
 		}
-	case "c":
+	case actionHold:
 		m.game.Hold()
-	case "p":
-		m.game.Paused = !m.game.Paused
-	case "q", "esc":
-		return m.setScreen(screenMenu)
+		return m.requestCoach()
+	case actionPause:
+		m.game.SetPaused(!m.game.Paused)
+	default:
+		if slices.Contains(reservedKeys, msg.String()) {
+			return m.setScreen(screenMenu)
+		}
 	}
 	return nil
 }
@@ -590,29 +759,140 @@ func (m *Model) updateThemes(msg tea.KeyMsg) tea.Cmd {
 }
 
 func (m *Model) updateScores(msg tea.KeyMsg) tea.Cmd {
+	if m.searching {
+		return m.updateScoresSearch(msg)
+	}
+	if m.scoreDetail {
+		switch msg.String() {
+		case "q", "esc", "enter":
+			m.scoreDetail = false
+		}
+		return nil
+	}
 	switch msg.String() {
-	case "q", "esc", "enter":
+	case "enter":
+		if m.scoresIndex < len(m.visibleScores()) {
+			m.scoreDetail = true
+		}
+	case "q", "esc":
 		cmd := m.setScreen(screenMenu)
 		if m.config.Sound {
 			return tea.Batch(cmd, playSound(m.sound, SoundMenuSelect))
 		}
 		return cmd
+	case "left", "h":
+		m.scoresTab = (m.scoresTab + len(scoresTabs) - 1) % len(scoresTabs)
+		m.scoresOffset, m.scoresIndex = 0, 0
+	case "right", "l":
+		m.scoresTab = (m.scoresTab + 1) % len(scoresTabs)
+		m.scoresOffset, m.scoresIndex = 0, 0
+	case "1", "2", "3":
+		m.scoresTab = int(msg.String()[0] - '1')
+		m.scoresOffset, m.scoresIndex = 0, 0
+	case "tab":
+		m.scoresWindow = (m.scoresWindow + 1) % len(scoreWindowTabs)
+		return m.reloadScores()
+	case "shift+tab":
+		m.scoresWindow = (m.scoresWindow + len(scoreWindowTabs) - 1) % len(scoreWindowTabs)
+		return m.reloadScores()
+	case "m":
+		m.scoresMode = (m.scoresMode + 1) % len(scoreModeFilters)
+		return m.reloadScores()
+	case "/":
+		m.searching = true
+		m.searchInput = m.scoresSearch
 	case "up", "k":
-		if m.scoresOffset > 0 {
-			m.scoresOffset--
+		if m.scoresIndex > 0 {
+			m.scoresIndex--
+		}
+		if m.scoresIndex < m.scoresOffset {
+			m.scoresOffset = m.scoresIndex
 		}
 	case "down", "j":
-		max := len(m.scores) - scoresPageSize
-		if max < 0 {
-			max = 0
+		rows := len(m.visibleScores())
+		if m.scoresIndex < rows-1 {
+			m.scoresIndex++
+		}
+		if m.scoresIndex >= m.scoresOffset+scoresPageSize {
+			m.scoresOffset = m.scoresIndex - scoresPageSize + 1
 		}
-		if m.scoresOffset < max {
-			m.scoresOffset++
+		if m.scoresTab != scoresTabLocal && !m.syncLoading && m.scoresOffset+scoresPageSize >= rows {
+			return m.fetchScoresPage()
 		}
 	}
 	return nil
 }
 
+func (m *Model) updateScoresSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.searching = false
+		m.scoresSearch = strings.TrimSpace(m.searchInput)
+		return m.reloadScores()
+	case tea.KeyEsc:
+		m.searching = false
+	case tea.KeyBackspace:
+		if len(m.searchInput) > 0 {
+			m.searchInput = m.searchInput[:len(m.searchInput)-1]
+		}
+	case tea.KeyRunes:
+		if len(m.searchInput) < 12 {
+			m.searchInput += string(msg.Runes)
+		}
+	}
+	return nil
+}
+
+func (m Model) scoresQuery() ScoreQuery {
+	return ScoreQuery{
+		Limit:  remotePageSize,
+		Offset: len(m.remoteScores),
+		Mode:   scoreModeFilters[m.scoresMode],
+		Search: m.scoresSearch,
+		Window: scoreWindowTabs[m.scoresWindow].window,
+	}
+}
+
+func (m Model) visibleScores() []scoreRow {
+	query := m.scoresQuery()
+	query.Limit, query.Offset = 0, 0
+	now := time.Now()
+	remote := m.remoteScores
+	if live := filterScores(m.liveScores, query, now); len(live) > 0 {
+		remote = dedupeScores(remote, live)
+	}
+	return scoreRows(filterScores(m.scores, query, now), remote, m.scoresTab)
+}
+
+func (m *Model) resubscribe() tea.Cmd {
+	m.live.Close()
+	m.live = m.sync.Subscribe()
+	m.liveStatus = ""
+	m.liveScores = nil
+	return waitLiveCmd(m.live)
+}
+
+func (m *Model) reloadScores() tea.Cmd {
+	m.scoresOffset, m.scoresIndex = 0, 0
+	m.remoteScores = nil
+	m.liveScores = nil
+	m.remoteDone = false
+	return m.fetchScoresPage()
+}
+
+func (m *Model) fetchScoresPage() tea.Cmd {
+	if !m.sync.Enabled() || m.remoteDone {
+		return nil
+	}
+	cmds := []tea.Cmd{m.sync.FetchScoresCmd(m.scoresQuery())}
+	if !m.syncLoading {
+		m.syncDots = 0
+		cmds = append(cmds, syncTickCmd())
+	}
+	m.syncLoading = true
+	return tea.Batch(cmds...)
+}
+
 func (m *Model) updateConfig(msg tea.KeyMsg) tea.Cmd {
 	switch msg.String() {
 	case "up", "k":
@@ -676,6 +956,19 @@ func (m *Model) updateConfig(msg tea.KeyMsg) tea.Cmd {
 				m.sync.SetEnabled(m.config.Sync)
 			}
 			_ = saveConfig(m.config)
+			live := m.resubscribe()
+			if m.config.Sound {
+				return tea.Batch(live, playSound(m.sound, SoundMenuSelect))
+			}
+			return live
+		case 8:
+			m.config.Coach = !m.config.Coach
+			m.coachHint = nil
+			m.coachHold = false
+			_ = saveConfig(m.config)
+		case 9:
+			m.config.StatsPanel = !m.config.StatsPanel
+			_ = saveConfig(m.config)
 		}
 		if m.config.Sound {
 			return playSound(m.sound, SoundMenuSelect)
@@ -719,26 +1012,13 @@ func (m *Model) updateNameEntry(msg tea.KeyMsg) tea.Cmd {
 		if name == "" {
 			name = "AAA"
 		}
-		entry := ScoreEntry{
-			Name:  name,
-			Score: m.game.Score,
-			Lines: m.game.Lines,
-			Level: m.game.Level,
-			When:  time.Now().Format("2006-01-02 15:04"),
-		}
-		if m.sync == nil || !m.sync.Enabled() {
-			m.scores = insertScore(m.scores, entry)
-			_ = saveScores(m.scores)
-		}
-		m.scoresOffset = 0
+		entry := m.recordHistory(name).ScoreEntry()
+		m.scores = insertScore(m.scores, entry)
+		m.scoresOffset, m.scoresIndex = 0, 0
 		cmd := m.setScreen(screenScores)
 		var cmds []tea.Cmd
 		if m.sync != nil && m.sync.Enabled() {
-			m.syncLoading = true
-			m.syncDots = 0
-			cmds = append(cmds, m.sync.UploadScoreCmd(entry))
-			cmds = append(cmds, m.sync.FetchScoresCmd())
-			cmds = append(cmds, syncTickCmd())
+			cmds = append(cmds, m.sync.UploadScoreCmd(entry), m.reloadScores())
 		}
 		if len(cmds) == 0 {
 			return cmd
@@ -754,16 +1034,41 @@ func (m *Model) updateNameEntry(msg tea.KeyMsg) tea.Cmd {
 			m.nameInput += string(msg.Runes)
 		}
 	case tea.KeyEsc:
-		return m.setScreen(screenMenu)
+		return m.setScreen(screenResults)
 	}
 	return nil
 }
 
+const (
+	scoresTabLocal = iota
+	scoresTabGlobal
+	scoresTabMerged
+)
+
+var scoresTabs = []string{"Local", "Global", "Merged"}
+
+const remotePageSize = 50
+
+var scoreWindowTabs = []struct {
+	window string
+	label  string
+}{
+	{scoreWindowAll, "All time"},
+	{scoreWindowDay, "Last 24h"},
+	{scoreWindowWeek, "Last 7 days"},
+}
+
+var scoreModeFilters = []string{"", gameModeMarathon, gameModeBattle}
+
 var menuItems = []string{
 	"Start Game",
+	"Battle",
+	"Finesse Trainer",
 	"Themes",
 	"Scores",
+	"Stats",
 	"Config",
+	"Profiles",
 	"Quit",
 }
 
@@ -776,9 +1081,12 @@ var configItems = []string{
 	"Hard Drop Trace",
 	"Game Scale",
 	"Score Sync",
+	"Coach",
+	"Stats Panel",
 }
 
 func (m *Model) applyScoreEvent(result LockResult) tea.Cmd {
+	m.sendAttack(result)
 	var animCmd tea.Cmd
 	if len(result.ClearedRows) > 0 {
 		if m.config.Animations {
@@ -853,6 +1161,7 @@ func (m *Model) isHardDropTraceAnimating() bool {
 }
 
 func (m *Model) startTopOutEffect() tea.Cmd {
+	m.game.StopClock()
 	m.flashRows = make([]int, boardHeight)
 	for i := 0; i < boardHeight; i++ {
 		m.flashRows[i] = i
@@ -918,6 +1227,13 @@ func (m *Model) startHardDropTrace() tea.Cmd {
 	return hardDropTraceTickCmd()
 }
 
+func (m *Model) publishSpectator() {
+	if m.spectate == nil || m.screen != screenGame {
+		return
+	}
+	m.spectate.Publish(m.game, resolveGameTheme(*m).Name)
+}
+
 func (m *Model) applyMoveBuffer() {
 	if m.lastMoveDir == 0 {
 		return
@@ -925,7 +1241,7 @@ func (m *Model) applyMoveBuffer() {
 	if time.Since(m.lastMoveAt) > 140*time.Millisecond {
 		return
 	}
-	if m.game.Move(m.lastMoveDir) && m.config.Sound {
+	if m.game.bufferedMove(m.lastMoveDir) && m.config.Sound {
 		_ = playSound(m.sound, SoundMove)
 	}
 }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	outboxBaseDelay = 30 * time.Second
+	outboxMaxDelay  = time.Hour
+)
+
+// outboxFlushing keeps startup and the scores screen from uploading the
+// same entries at once.
+var outboxFlushing sync.Mutex
+
+type OutboxItem struct {
+	Entry     ScoreEntry `json:"entry"`
+	Attempts  int        `json:"attempts"`
+	NextTry   time.Time  `json:"next_try"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+type outboxFlushedMsg struct {
+	pending int
+	sent    int
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		return outboxMaxDelay
+	}
+	return delay
+}
+
+func loadOutbox() ([]OutboxItem, error) {
+	path, err := outboxPath()
+	if err != nil {
+		return nil, err
+	}
+	items := []OutboxItem{}
+	if _, err := readJSONFile(path, &items); os.IsNotExist(err) {
+		return []OutboxItem{}, nil
+	} else if err != nil {
+		return []OutboxItem{}, err
+	}
+	return items, nil
+}
+
+func saveOutbox(items []OutboxItem) error {
+	path, err := outboxPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func updateOutbox(change func([]OutboxItem) []OutboxItem) (int, error) {
+	path, err := outboxPath()
+	if err != nil {
+		return 0, err
+	}
+	unlock, err := lockFile(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	items, err := loadOutbox()
+	if err != nil {
+		return 0, err
+	}
+	items = change(items)
+	return len(items), saveOutbox(items)
+}
+
+func queueUpload(entry ScoreEntry, uploadErr error) (int, error) {
+	if entry.ID == "" {
+		entry.ID = newScoreID()
+	}
+	return updateOutbox(func(items []OutboxItem) []OutboxItem {
+		return append(items, OutboxItem{
+			Entry:     entry,
+			Attempts:  1,
+			NextTry:   time.Now().Add(outboxBackoff(1)),
+			LastError: uploadErr.Error(),
+		})
+	})
+}
+
+func outboxPending() int {
+	items, err := loadOutbox()
+	if err != nil {
+		DebugLogf("outbox load error: %v", err)
+	}
+	return len(items)
+}
+
+func flushOutbox(sync *ScoreSync, now time.Time) (int, int, error) {
+	if !outboxFlushing.TryLock() {
+		return outboxPending(), 0, nil
+	}
+	defer outboxFlushing.Unlock()
+	items, err := loadOutbox()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, item := range items {
+		if item.Entry.ID == "" {
+			if items, err = assignOutboxIDs(); err != nil {
+				return 0, 0, err
+			}
+			break
+		}
+	}
+	results := map[string]error{}
+	for _, item := range items {
+		key := scoreKey(item.Entry)
+		if _, tried := results[key]; tried || now.Before(item.NextTry) {
+			continue
+		}
+		results[key] = sync.UploadScore(item.Entry)
+	}
+	if len(results) == 0 {
+		return len(items), 0, nil
+	}
+	sent := 0
+	pending, err := updateOutbox(func(items []OutboxItem) []OutboxItem {
+		kept := make([]OutboxItem, 0, len(items))
+		for _, item := range items {
+			uploadErr, tried := results[scoreKey(item.Entry)]
+			switch {
+			case !tried:
+				kept = append(kept, item)
+			case uploadErr == nil:
+				sent++
+			case scoreRejected(uploadErr):
+				DebugLogf("outbox dropped rejected score name=%s: %v", item.Entry.Name, uploadErr)
+			default:
+				item.Attempts++
+				item.NextTry = now.Add(outboxBackoff(item.Attempts))
+				item.LastError = uploadErr.Error()
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	})
+	return pending, sent, err
+}
+
+// assignOutboxIDs gives entries queued by older builds an ID, so they no
+// longer dedupe on the minute-resolution fallback key.
+func assignOutboxIDs() ([]OutboxItem, error) {
+	var assigned []OutboxItem
+	_, err := updateOutbox(func(items []OutboxItem) []OutboxItem {
+		for i := range items {
+			if items[i].Entry.ID == "" {
+				items[i].Entry.ID = newScoreID()
+			}
+		}
+		assigned = items
+		return items
+	})
+	return assigned, err
+}
+
+func flushOutboxCmd(sync *ScoreSync) tea.Cmd {
+	if !sync.Enabled() {
+		return nil
+	}
+	return func() tea.Msg {
+		pending, sent, err := flushOutbox(sync, time.Now())
+		if err != nil {
+			DebugLogf("outbox flush error: %v", err)
+			return outboxFlushedMsg{pending: outboxPending()}
+		}
+		DebugLogf("outbox flush sent=%d pending=%d", sent, pending)
+		return outboxFlushedMsg{pending: pending, sent: sent}
+	}
+}
+
+func outboxPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "outbox.json"), nil
+}
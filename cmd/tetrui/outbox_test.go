@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingBackend struct {
+	memoryBackend
+	submits atomic.Int32
+}
+
+func (b *countingBackend) Submit(entry ScoreEntry) error {
+	b.submits.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	return b.memoryBackend.Submit(entry)
+}
+
+func TestQueueUploadAssignsIDs(t *testing.T) {
+	useTempDataDir(t)
+	entry := ScoreEntry{Name: "ann", Score: 100, When: "2026-01-02 15:04"}
+	for range 2 {
+		if _, err := queueUpload(entry, errors.New("offline")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err := loadOutbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Entry.ID == "" || items[0].Entry.ID == items[1].Entry.ID {
+		t.Fatalf("queued %+v", items)
+	}
+}
+
+func TestFlushOutboxOnce(t *testing.T) {
+	useTempDataDir(t)
+	for _, name := range []string{"ann", "bob"} {
+		if _, err := queueUpload(ScoreEntry{Name: name, Score: 100, When: "2026-01-02 15:04"}, errors.New("offline")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backend := &countingBackend{}
+	scores := &ScoreSync{enabled: true, backend: backend}
+	later := time.Now().Add(time.Hour)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := flushOutbox(scores, later); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := backend.submits.Load(); got != 2 {
+		t.Fatalf("submitted %d times, want 2", got)
+	}
+	if pending := outboxPending(); pending != 0 {
+		t.Fatalf("%d entries left in the outbox", pending)
+	}
+}
+
+func TestFlushOutboxAssignsLegacyIDs(t *testing.T) {
+	useTempDataDir(t)
+	legacy := []OutboxItem{
+		{Entry: ScoreEntry{Name: "ann", Score: 100, When: "2026-01-02 15:04"}, Attempts: 1},
+		{Entry: ScoreEntry{Name: "ann", Score: 100, When: "2026-01-02 15:04"}, Attempts: 1},
+	}
+	if err := saveOutbox(legacy); err != nil {
+		t.Fatal(err)
+	}
+	backend := &countingBackend{}
+	_, sent, err := flushOutbox(&ScoreSync{enabled: true, backend: backend}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 || backend.submits.Load() != 2 {
+		t.Fatalf("sent %d, submitted %d, want both games", sent, backend.submits.Load())
+	}
+}
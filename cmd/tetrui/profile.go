@@ -0,0 +1,258 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	defaultProfileLabel = "Default"
+	newProfileLabel     = "New profile"
+	profileNameLimit    = 12
+	lastProfileFile     = "last_profile"
+)
+
+var activeProfile string
+
+func SetProfile(name string) {
+	activeProfile = name
+}
+
+func validProfileName(name string) bool {
+	if name == "" || len(name) > profileNameLimit {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func baseDir() (string, error) {
+	if dir, ok := portableDir(); ok {
+		return dir, nil
+	}
+	root, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "tetrui"), nil
+}
+
+func dataDir() (string, error) {
+	dir, err := baseDir()
+	if err != nil {
+		return "", err
+	}
+	if activeProfile != "" {
+		dir = filepath.Join(dir, "profiles", activeProfile)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func listProfiles() ([]string, error) {
+	dir, err := baseDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	profiles := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && validProfileName(entry.Name()) {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// lastProfile returns the profile picked last time ("" for Default). ok is
+// false until a profile has been picked, or when that profile is gone.
+func lastProfile() (string, bool) {
+	dir, err := baseDir()
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, lastProfileFile))
+	if err != nil {
+		return "", false
+	}
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return "", true
+	}
+	if !validProfileName(name) {
+		return "", false
+	}
+	if info, err := os.Stat(filepath.Join(dir, "profiles", name)); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return name, true
+}
+
+func saveLastProfile(name string) error {
+	dir, err := baseDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return replaceFile(filepath.Join(dir, lastProfileFile), []byte(name+"\n"))
+}
+
+func (m *Model) openProfiles() tea.Cmd {
+	profiles, err := listProfiles()
+	if err != nil {
+		DebugLogf("profile list error: %v", err)
+	}
+	m.profiles = profiles
+	m.profileIndex = 0
+	for i, name := range m.profileItems() {
+		if name == activeProfile {
+			m.profileIndex = i
+		}
+	}
+	m.profileNew = false
+	m.profileInput = ""
+	return m.setScreen(screenProfiles)
+}
+
+func (m *Model) profileItems() []string {
+	items := append([]string{defaultProfileLabel}, m.profiles...)
+	return append(items, newProfileLabel)
+}
+
+func (m *Model) applyProfile(name string) tea.Cmd {
+	SetProfile(name)
+	if err := saveLastProfile(name); err != nil {
+		DebugLogf("profile save error: %v", err)
+	}
+	config, issues, err := loadConfig()
+	if err != nil {
+		DebugLogf("profile config load error: %v", err)
+	}
+	index := themeIndexByName(config.Theme)
+	if index < 0 {
+		index = 0
+		config.Theme = themes[index].Name
+	}
+	m.config = config
+	m.configIssues = issues
+	m.outboxCount = outboxPending()
+	m.themeIndex = index
+	if m.sound != nil {
+		m.sound.SetEnabled(config.Sound)
+		m.sound.SetVolume(volumeFromPercent(config.Volume))
+	}
+	if m.music != nil {
+		m.music.SetVolume(volumeFromPercent(config.Volume))
+		if !config.Music {
+			m.music.Stop()
+		}
+	}
+	m.sync = NewScoreSync(config)
+	live := m.resubscribe()
+	m.scores, err = loadScores()
+	if err != nil {
+		DebugLogf("profile scores load error: %v", err)
+	}
+	m.remoteScores = nil
+	m.remoteDone = false
+	return tea.Batch(m.setScreen(screenMenu), live)
+}
+
+func (m *Model) updateProfiles(msg tea.KeyMsg) tea.Cmd {
+	if m.profileNew {
+		switch msg.Type {
+		case tea.KeyEnter:
+			name := strings.TrimSpace(m.profileInput)
+			if !validProfileName(name) {
+				return nil
+			}
+			return m.applyProfile(name)
+		case tea.KeyBackspace, tea.KeyDelete:
+			if len(m.profileInput) > 0 {
+				m.profileInput = m.profileInput[:len(m.profileInput)-1]
+			}
+		case tea.KeyRunes:
+			if len(m.profileInput) < profileNameLimit {
+				m.profileInput += string(msg.Runes)
+			}
+		case tea.KeyEsc:
+			m.profileNew = false
+			m.profileInput = ""
+		}
+		return nil
+	}
+	items := m.profileItems()
+	switch msg.String() {
+	case "up", "k":
+		if m.profileIndex > 0 {
+			m.profileIndex--
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "down", "j":
+		if m.profileIndex < len(items)-1 {
+			m.profileIndex++
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "enter":
+		switch {
+		case m.profileIndex == 0:
+			return m.applyProfile("")
+		case m.profileIndex == len(items)-1:
+			m.profileNew = true
+			m.profileInput = ""
+		default:
+			return m.applyProfile(items[m.profileIndex])
+		}
+	case "q", "esc":
+		// Before the first pick there is no menu to go back to.
+		if _, chosen := lastProfile(); chosen {
+			return m.setScreen(screenMenu)
+		}
+		return tea.Quit
+	}
+	return nil
+}
+
+func viewProfiles(m Model) string {
+	theme := themes[m.themeIndex]
+	if m.profileNew {
+		var b strings.Builder
+		b.WriteString(titleStyle(theme).Render(newProfileLabel))
+		b.WriteString("\n\n")
+		b.WriteString("Profile name: ")
+		b.WriteString(highlightStyle(theme).Render(m.profileInput))
+		b.WriteString("\n\n")
+		if m.profileInput != "" && !validProfileName(m.profileInput) {
+			b.WriteString("Use letters, digits, - and _ only.\n\n")
+		}
+		b.WriteString(helpStyle(theme).Render("Enter to create, Esc to back"))
+		return center(m.width, m.height, b.String())
+	}
+	content := renderMenu("Who's playing?", m.profileItems(), m.profileIndex, "Enter to select, Q to quit", theme)
+	return center(m.width, m.height, content)
+}
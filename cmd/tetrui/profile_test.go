@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOpenProfilesFirstRun(t *testing.T) {
+	useTempDataDir(t)
+	if _, chosen := lastProfile(); chosen {
+		t.Fatal("a profile counts as chosen before the picker ran")
+	}
+	m := Model{}
+	m.openProfiles()
+	if m.screen != screenProfiles {
+		t.Fatal("picker not shown without profiles")
+	}
+	if items := m.profileItems(); !slices.Equal(items, []string{defaultProfileLabel, newProfileLabel}) {
+		t.Fatalf("items %v", items)
+	}
+}
+
+func TestLastProfile(t *testing.T) {
+	useTempDataDir(t)
+	m := Model{}
+	m.openProfiles()
+	m.updateProfiles(tea.KeyMsg{Type: tea.KeyDown})
+	m.updateProfiles(tea.KeyMsg{Type: tea.KeyEnter})
+	for _, r := range "alice" {
+		m.updateProfiles(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m.updateProfiles(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.screen != screenMenu || activeProfile != "alice" {
+		t.Fatalf("screen %v, profile %q after creating alice", m.screen, activeProfile)
+	}
+	SetProfile("")
+	if name, chosen := lastProfile(); !chosen || name != "alice" {
+		t.Fatalf("last profile = %q, %v", name, chosen)
+	}
+	m.openProfiles()
+	if items := m.profileItems(); items[m.profileIndex] != defaultProfileLabel {
+		t.Fatalf("picker starts on %q, want the active Default", items[m.profileIndex])
+	}
+	m.updateProfiles(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.screen != screenMenu {
+		t.Fatal("Esc did not return to the menu once a profile was chosen")
+	}
+	if err := saveLastProfile("gone"); err != nil {
+		t.Fatal(err)
+	}
+	if _, chosen := lastProfile(); chosen {
+		t.Fatal("a deleted profile still counts as chosen")
+	}
+}
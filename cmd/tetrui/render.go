@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -102,6 +103,11 @@ func themeIndexByName(name string) int {
 
 func viewMenu(m Model) string {
 	theme := themes[m.themeIndex]
+	if !m.attractTil.IsZero() {
+		theme = fadeTheme(theme, animationProgress(time.Now(), m.attractFrom, m.attractTil))
+		content := renderMenu("TETRUI", menuItems, m.menuIndex, "Enter to select, Q to quit", theme)
+		return center(m.width, m.height, lipgloss.NewStyle().Foreground(theme.TextColor).Render(content))
+	}
 	content := renderMenu("TETRUI", menuItems, m.menuIndex, "Enter to select, Q to quit", theme)
 	return center(m.width, m.height, content)
 }
@@ -180,22 +186,65 @@ func viewScores(m Model) string {
 	var b strings.Builder
 	b.WriteString(titleStyle(theme).Render("Scores"))
 	b.WriteString("\n\n")
-	if len(m.scores) == 0 {
+	tabs := make([]string, 0, len(scoresTabs))
+	for i, tab := range scoresTabs {
+		if i == m.scoresTab {
+			tabs = append(tabs, highlightStyle(theme).Render("["+tab+"]"))
+			continue
+		}
+		tabs = append(tabs, " "+tab+" ")
+	}
+	b.WriteString(strings.Join(tabs, " "))
+	b.WriteString("\n")
+	windows := make([]string, 0, len(scoreWindowTabs))
+	for i, tab := range scoreWindowTabs {
+		if i == m.scoresWindow {
+			windows = append(windows, highlightStyle(theme).Render("["+tab.label+"]"))
+			continue
+		}
+		windows = append(windows, " "+tab.label+" ")
+	}
+	b.WriteString(strings.Join(windows, " "))
+	b.WriteString("\n")
+	mode := scoreModeFilters[m.scoresMode]
+	if mode == "" {
+		mode = "all"
+	}
+	filter := "Mode: " + mode
+	if m.searching {
+		filter += "  Search: " + m.searchInput + "_"
+	} else if m.scoresSearch != "" {
+		filter += "  Search: " + m.scoresSearch
+	}
+	b.WriteString(helpStyle(theme).Render(filter))
+	b.WriteString("\n\n")
+	rows := m.visibleScores()
+	if m.scoreDetail && m.scoresIndex < len(rows) {
+		b.WriteString(viewScoreDetail(rows[m.scoresIndex]))
+		b.WriteString("\n")
+		b.WriteString(helpStyle(theme).Render("Enter or Esc to close"))
+		return center(m.width, m.height, b.String())
+	}
+	if len(rows) == 0 {
 		b.WriteString("No scores yet.\n")
 	} else {
 		start := m.scoresOffset
 		end := start + scoresPageSize
-		if end > len(m.scores) {
-			end = len(m.scores)
+		if end > len(rows) {
+			end = len(rows)
 		}
-		for i, score := range m.scores[start:end] {
-			line := fmt.Sprintf("%2d. %-12s %7d  L%2d  %s", start+i+1, score.Name, score.Score, score.Level, score.When)
+		for i, row := range rows[start:end] {
+			score := row.Entry
+			line := fmt.Sprintf("%2d. %-12s %7d  L%2d  %s  %-3s", start+i+1, score.Name, score.Score, score.Level, formatScoreTime(score.When), row.Badge())
+			if start+i == m.scoresIndex {
+				line = highlightStyle(theme).Render(line)
+			}
 			b.WriteString(line)
 			b.WriteString("\n")
 		}
-		if len(m.scores) > scoresPageSize {
+		if len(rows) > scoresPageSize || !m.remoteDone && m.scoresTab != scoresTabLocal && m.sync.Enabled() {
 			b.WriteString("\n")
-			b.WriteString(helpStyle(theme).Render("Use Up/Down to scroll"))
+			b.WriteString(helpStyle(theme).Render("Use j/k to scroll"))
 			b.WriteString("\n")
 		}
 	}
@@ -204,18 +253,81 @@ func viewScores(m Model) string {
 		b.WriteString(warningStyle(theme).Render(m.syncWarning))
 		b.WriteString("\n")
 	}
+	if m.live != nil && m.liveStatus != "" && m.scoresTab != scoresTabLocal {
+		b.WriteString("\n")
+		b.WriteString(helpStyle(theme).Render(m.liveStatus))
+		b.WriteString("\n")
+	}
+	if m.outboxCount > 0 {
+		b.WriteString("\n")
+		b.WriteString(warningStyle(theme).Render(fmt.Sprintf("Pending uploads: %d (will retry)", m.outboxCount)))
+		b.WriteString("\n")
+	}
 	if m.syncLoading {
 		b.WriteString("\n")
 		b.WriteString(helpStyle(theme).Render(renderSyncLoader(m.syncDots)))
 		b.WriteString("\n")
 	}
 	b.WriteString("\n")
-	b.WriteString(helpStyle(theme).Render("Enter to back"))
+	if m.searching {
+		b.WriteString(helpStyle(theme).Render("Type a name, Enter to search, Esc to cancel"))
+	} else {
+		b.WriteString(helpStyle(theme).Render("Left/Right source, Tab window, m mode, / search, Enter details, Esc to back"))
+	}
 	return center(m.width, m.height, b.String())
 }
 
 const scoresPageSize = 20
 
+func viewScoreDetail(row scoreRow) string {
+	score := row.Entry
+	text := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+	number := func(value int64) string {
+		if value == 0 {
+			return "-"
+		}
+		return strconv.FormatInt(value, 10)
+	}
+	duration := "-"
+	if score.DurationMs > 0 {
+		duration = formatPlayTime(time.Duration(score.DurationMs) * time.Millisecond)
+	}
+	source := "Local"
+	if row.Local && row.Remote {
+		source = "Local and global"
+	} else if row.Remote {
+		source = "Global"
+	}
+	fields := [][2]string{
+		{"Name", score.Name},
+		{"Score", strconv.Itoa(score.Score)},
+		{"Lines", strconv.Itoa(score.Lines)},
+		{"Level", strconv.Itoa(score.Level)},
+		{"Played", text(formatScoreTime(score.When))},
+		{"Mode", text(score.Mode)},
+		{"Rule set", text(score.RuleSet)},
+		{"Duration", duration},
+		{"Pieces", number(int64(score.Pieces))},
+		{"Max combo", number(int64(score.MaxCombo))},
+		{"Seed", number(score.Seed)},
+		{"Version", text(score.Version)},
+		{"Platform", text(score.Platform)},
+		{"Board", text(score.Board)},
+		{"Source", source},
+		{"ID", text(score.ID)},
+	}
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(fmt.Sprintf("%-10s  %s\n", field[0], field[1]))
+	}
+	return b.String()
+}
+
 func viewConfig(m Model) string {
 	theme := themes[m.themeIndex]
 	items := make([]string, 0, len(configItems))
@@ -256,22 +368,44 @@ func viewConfig(m Model) string {
 				state = "ON"
 			}
 			items = append(items, fmt.Sprintf("%s: %s", item, state))
+		case 8:
+			if m.config.Coach {
+				state = "ON"
+			}
+			items = append(items, fmt.Sprintf("%s: %s", item, state))
+		case 9:
+			if m.config.StatsPanel {
+				state = "ON"
+			}
+			items = append(items, fmt.Sprintf("%s: %s", item, state))
 		}
 	}
 	content := renderMenu("Config", items, m.configIndex, "Enter to toggle, Left/Right to adjust, Esc to back", theme)
+	if len(m.configIssues) > 0 {
+		lines := []string{"", titleStyle(theme).Render("Problems in config.json (defaults used)")}
+		for _, issue := range m.configIssues {
+			lines = append(lines, warningStyle(theme).Render(issue))
+		}
+		content = lipgloss.JoinVertical(lipgloss.Center, append([]string{content}, lines...)...)
+	}
 	return center(m.width, m.height, content)
 }
 
 func viewNameEntry(m Model) string {
 	theme := themes[m.themeIndex]
 	var b strings.Builder
-	b.WriteString(titleStyle(theme).Render("Game Over"))
+	title := "Game Over"
+	if m.battle.won {
+		title = "You Win"
+	}
+	b.WriteString(titleStyle(theme).Render(title))
 	b.WriteString("\n\n")
-	b.WriteString(fmt.Sprintf("Score: %d  Lines: %d  Level: %d\n\n", m.game.Score, m.game.Lines, m.game.Level))
+	b.WriteString(fmt.Sprintf("Score: %d  Lines: %d  Level: %d\n", m.game.Score, m.game.Lines, m.game.Level))
+	b.WriteString(fmt.Sprintf("Finesse faults: %d\n\n", m.game.Finesse))
 	b.WriteString("Enter your name: ")
 	b.WriteString(highlightStyle(theme).Render(m.nameInput))
 	b.WriteString("\n\n")
-	b.WriteString(helpStyle(theme).Render("Enter to save, Esc to skip"))
+	b.WriteString(helpStyle(theme).Render("Enter to save, Esc to back"))
 	return center(m.width, m.height, b.String())
 }
 
@@ -295,6 +429,7 @@ func viewGame(m Model) string {
 		m.hardDropDest,
 		m.hardDropFrom,
 		m.hardDropTil,
+		m.coachHint,
 	)
 	readyLabel := ""
 	if m.startCount > 0 {
@@ -304,11 +439,21 @@ func viewGame(m Model) string {
 			readyLabel = "GO"
 		}
 	}
-	info := renderInfo(m.game, theme, scale, m.lastEvent, m.lastDelta, readyLabel)
+	info := renderInfo(m.game, theme, scale, m.lastEvent, m.lastDelta, readyLabel, m.config.keyHelp())
+	if m.coachHold && len(m.coachHint) > 0 {
+		coach := lipgloss.NewStyle().PaddingLeft(2).Render(highlightStyle(theme).Render(m.config.coachHoldLabel()))
+		info = lipgloss.JoinVertical(lipgloss.Left, info, "", coach)
+	}
 	content := lipgloss.JoinHorizontal(lipgloss.Top, board, info)
 	if m.width < minWidth+24 {
 		content = lipgloss.JoinVertical(lipgloss.Left, board, info)
 	}
+	if m.config.StatsPanel {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, content, renderStats(m.game.LiveStats(), theme))
+	}
+	if m.battle.active {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, content, renderOpponent(m, theme))
+	}
 	if m.isTopOutAnimating() {
 		shake := ((time.Now().UnixNano() / int64(18*time.Millisecond)) % 2)
 		if shake == 0 {
@@ -347,7 +492,7 @@ func levelShiftThemeIndices() []int {
 	return indices
 }
 
-func renderBoard(g Game, theme Theme, scale int, showShadow bool, flashRows []int, flashStart time.Time, flashUntil time.Time, hardDropPath []Point, hardDropDest []Point, hardDropFrom time.Time, hardDropUntil time.Time) string {
+func renderBoard(g Game, theme Theme, scale int, showShadow bool, flashRows []int, flashStart time.Time, flashUntil time.Time, hardDropPath []Point, hardDropDest []Point, hardDropFrom time.Time, hardDropUntil time.Time, hint []Point) string {
 	border := lipgloss.NewStyle().Foreground(theme.BorderColor)
 	cellEmpty := lipgloss.NewStyle()
 	cellText := strings.Repeat(" ", cellWidth(scale))
@@ -408,6 +553,12 @@ func renderBoard(g Game, theme Theme, scale int, showShadow bool, flashRows []in
 			}
 		}
 	}
+	hintMap := map[Point]struct{}{}
+	for _, point := range hint {
+		hintMap[point] = struct{}{}
+	}
+	hintStyle := lipgloss.NewStyle().Foreground(theme.AccentColor).Bold(true)
+	hintText := "[" + strings.Repeat(" ", cellWidth(scale)-2) + "]"
 	whiteStyle := lipgloss.NewStyle().Background(lipgloss.Color("15"))
 	hardDropPathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Faint(true)
 	hardDropPathText := strings.Repeat(".", cellWidth(scale))
@@ -439,6 +590,10 @@ func renderBoard(g Game, theme Theme, scale int, showShadow bool, flashRows []in
 					continue
 				}
 				if val == 0 {
+					if _, ok := hintMap[point]; ok {
+						b.WriteString(hintStyle.Render(hintText))
+						continue
+					}
 					if ghost[y][x] {
 						color := theme.PieceColors[g.Current%len(theme.PieceColors)]
 						ghostText := strings.Repeat(".", cellWidth(scale))
@@ -449,6 +604,9 @@ func renderBoard(g Game, theme Theme, scale int, showShadow bool, flashRows []in
 					continue
 				}
 				color := theme.PieceColors[(val-1)%len(theme.PieceColors)]
+				if val == garbageCell {
+					color = lipgloss.Color("240")
+				}
 				style := lipgloss.NewStyle().Background(color)
 				b.WriteString(style.Render(cellText))
 			}
@@ -527,7 +685,7 @@ func brokenColumns(now, start, until time.Time) int {
 	return columns
 }
 
-func renderInfo(g Game, theme Theme, scale int, lastEvent string, lastDelta int, readyLabel string) string {
+func renderInfo(g Game, theme Theme, scale int, lastEvent string, lastDelta int, readyLabel string, keys []string) string {
 	var b strings.Builder
 	pad := lipgloss.NewStyle().PaddingLeft(2)
 	if readyLabel != "" {
@@ -551,6 +709,8 @@ func renderInfo(g Game, theme Theme, scale int, lastEvent string, lastDelta int,
 	b.WriteString(pad.Render(fmt.Sprintf("Lines: %d", g.Lines)))
 	b.WriteString("\n")
 	b.WriteString(pad.Render(fmt.Sprintf("Level: %d", g.Level)))
+	b.WriteString("\n")
+	b.WriteString(pad.Render(fmt.Sprintf("Finesse: %d", g.Finesse)))
 	b.WriteString("\n\n")
 	if lastEvent != "" || lastDelta > 0 {
 		label := lastEvent
@@ -573,14 +733,6 @@ func renderInfo(g Game, theme Theme, scale int, lastEvent string, lastDelta int,
 	if g.Combo > 1 || g.BackToBack > 1 {
 		b.WriteString("\n")
 	}
-	keys := []string{
-		"Arrows/HJKL: move",
-		"Z/X or Up: rotate",
-		"Space: hard drop",
-		"C: hold",
-		"P: pause",
-		"Q: menu",
-	}
 	for _, line := range keys {
 		b.WriteString(pad.Render(helpStyle(theme).Render(line)))
 		b.WriteString("\n")
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	inputLeft     = 'L'
+	inputRight    = 'R'
+	inputRotateCW = 'X'
+	inputRotateCC = 'Z'
+	inputSoftDrop = 'S'
+	inputHardDrop = 'H'
+	inputHold     = 'C'
+	inputPlace    = 'P'
+	inputGravity  = 'g'
+	inputLock     = 'k'
+	inputResolve  = 'r'
+	inputGarbage  = 'a'
+	inputOffset   = 'o'
+
+	replayCoordBase = 'K'
+	signatureHeader = "X-Tetrui-Signature"
+)
+
+func (g *Game) logInput(input ...byte) {
+	g.inputs = append(g.inputs, input...)
+}
+
+func (g *Game) logMove(dx int) {
+	if dx < 0 {
+		g.logInput(inputLeft)
+	} else {
+		g.logInput(inputRight)
+	}
+}
+
+func (g *Game) logRotate(dir int) {
+	if dir < 0 {
+		g.logInput(inputRotateCC)
+	} else {
+		g.logInput(inputRotateCW)
+	}
+}
+
+// logPlace adds 4 to the rotation byte when the placement was a spin.
+func (g *Game) logPlace(x, y, rotation int, spin bool) {
+	if spin {
+		rotation += 4
+	}
+	g.logInput(inputPlace, byte(replayCoordBase+x), byte(replayCoordBase+y), byte(replayCoordBase+rotation))
+}
+
+func (g *Game) logGarbage(lines int) {
+	for range lines {
+		g.logInput(inputGarbage)
+	}
+}
+
+func (g *Game) ReplayLog() string {
+	return string(g.inputs)
+}
+
+func replayHash(replay string) string {
+	sum := sha256.Sum256([]byte(replay))
+	return hex.EncodeToString(sum[:])
+}
+
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func validSignature(secret string, payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signPayload(secret, payload))
+	if err != nil {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	return err == nil && hmac.Equal(expected, got)
+}
+
+func replayGame(seed int64, replay string) (Game, error) {
+	g := NewGameWithSeed(seed)
+	attack := 0
+	for i := 0; i < len(replay); i++ {
+		if g.Over && replay[i] != inputGarbage && replay[i] != inputOffset {
+			return g, fmt.Errorf("input %d after game over", i)
+		}
+		switch replay[i] {
+		case inputLeft:
+			g.Move(-1)
+		case inputRight:
+			g.Move(1)
+		case inputRotateCW:
+			g.Rotate(1)
+		case inputRotateCC:
+			g.Rotate(-1)
+		case inputSoftDrop:
+			g.SoftDrop()
+		case inputHardDrop:
+			attack = g.HardDrop().Attack
+		case inputHold:
+			if !g.CanHold {
+				return g, fmt.Errorf("input %d: hold used twice", i)
+			}
+			g.Hold()
+		case inputPlace:
+			if i+3 >= len(replay) {
+				return g, fmt.Errorf("input %d: truncated placement", i)
+			}
+			x := int(replay[i+1]) - replayCoordBase
+			y := int(replay[i+2]) - replayCoordBase
+			rotation := int(replay[i+3]) - replayCoordBase
+			spin := rotation >= 4
+			if spin {
+				rotation -= 4
+			}
+			i += 3
+			result, ok := g.Place(x, y, rotation, spin)
+			if !ok {
+				return g, fmt.Errorf("input %d: impossible placement", i)
+			}
+			attack = result.Attack
+		case inputGravity:
+			if g.hasPendingLineClear() {
+				return g, fmt.Errorf("input %d: gravity during line clear", i)
+			}
+			g.fall()
+		case inputLock:
+			if g.hasPendingLineClear() || !g.collides(g.X, g.Y+1, g.Rotation) || g.lockStart.IsZero() {
+				return g, fmt.Errorf("input %d: lock before the piece landed", i)
+			}
+			attack = g.lockAndSpawn().Attack
+		case inputResolve:
+			if !g.hasPendingLineClear() {
+				return g, fmt.Errorf("input %d: no line clear to resolve", i)
+			}
+			g.ResolveLineClear()
+		case inputGarbage:
+			g.AddGarbage(1)
+		case inputOffset:
+			g.OffsetGarbage(attack)
+			attack = 0
+		default:
+			return g, fmt.Errorf("input %d: unknown input %q", i, replay[i])
+		}
+	}
+	return g, nil
+}
+
+func verifySubmission(score uploadScore) error {
+	if score.Replay == "" {
+		return errors.New("no replay")
+	}
+	if score.RuleSet != ruleSet {
+		return fmt.Errorf("rule set %q is not %q", score.RuleSet, ruleSet)
+	}
+	if score.InputHash != replayHash(score.Replay) {
+		return errors.New("input hash does not match the replay")
+	}
+	g, err := replayGame(score.Seed, score.Replay)
+	if err != nil {
+		return err
+	}
+	if g.Score != score.Score || g.Lines != score.Lines || g.Level != score.Level {
+		return fmt.Errorf("replay gives score %d, lines %d, level %d", g.Score, g.Lines, g.Level)
+	}
+	return nil
+}
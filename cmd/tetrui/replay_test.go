@@ -0,0 +1,194 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func playInputs(seed int64) Game {
+	g := NewGameWithSeed(seed)
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < 3000 && !g.Over; i++ {
+		if g.hasPendingLineClear() {
+			g.ResolveLineClear()
+			continue
+		}
+		var result LockResult
+		switch rng.Intn(9) {
+		case 0:
+			g.Move(-1)
+		case 1:
+			g.Move(1)
+		case 2:
+			g.Rotate(1)
+		case 3:
+			g.Rotate(-1)
+		case 4:
+			g.SoftDrop()
+		case 5:
+			result = g.HardDrop()
+		case 6:
+			g.Hold()
+		case 7:
+			g.AddGarbage(rng.Intn(2))
+		default:
+			if !g.lockStart.IsZero() {
+				g.lockStart = time.Now().Add(-lockDelay)
+			}
+			result = g.Step()
+		}
+		if result.Locked {
+			g.OffsetGarbage(result.Attack)
+		}
+	}
+	return g
+}
+
+func playBot(seed int64, pieces int) Game {
+	g := NewGameWithSeed(seed)
+	for i := 0; i < pieces && !g.Over; i++ {
+		placement, ok := choosePlacement(&g, 0, 0, nil)
+		if !ok {
+			break
+		}
+		if _, placed := playPlacement(&g, placement); !placed {
+			break
+		}
+		g.ResolveLineClear()
+	}
+	return g
+}
+
+func replayEntry(g Game) ScoreEntry {
+	return ScoreEntry{Name: "test", Score: g.Score, Lines: g.Lines, Level: g.Level, Seed: g.Seed, RuleSet: ruleSet, Replay: g.ReplayLog()}
+}
+
+func TestVerifyGenuineReplays(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		if err := verifySubmission(newUploadScore(replayEntry(playInputs(seed)))); err != nil {
+			t.Errorf("input game seed %d: %v", seed, err)
+		}
+		if err := verifySubmission(newUploadScore(replayEntry(playBot(seed, 60)))); err != nil {
+			t.Errorf("bot game seed %d: %v", seed, err)
+		}
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	human := newUploadScore(replayEntry(playInputs(3)))
+	bot := newUploadScore(replayEntry(playBot(3, 40)))
+	place := strings.IndexByte(bot.Replay, inputPlace)
+	if place < 0 {
+		t.Fatal("bot replay has no placement")
+	}
+	movedPlacement := []byte(bot.Replay)
+	movedPlacement[place+2] -= 3
+	tests := []struct {
+		name   string
+		score  uploadScore
+		change func(*uploadScore)
+	}{
+		{"score", human, func(s *uploadScore) { s.Score += 100 }},
+		{"lines", human, func(s *uploadScore) { s.Lines++ }},
+		{"hash", human, func(s *uploadScore) { s.InputHash = replayHash(s.Replay + "H") }},
+		{"replay", human, func(s *uploadScore) { s.Replay += "H" }},
+		{"seed", human, func(s *uploadScore) { s.Seed++ }},
+		{"rule set", human, func(s *uploadScore) { s.RuleSet = "other" }},
+		{"placement", bot, func(s *uploadScore) {
+			s.Replay = string(movedPlacement)
+			s.InputHash = replayHash(s.Replay)
+		}},
+		{"early lock", human, func(s *uploadScore) {
+			s.Replay = "kkk"
+			s.InputHash = replayHash(s.Replay)
+		}},
+	}
+	for _, test := range tests {
+		score := test.score
+		test.change(&score)
+		if err := verifySubmission(score); err == nil {
+			t.Errorf("%s: tampered score was accepted", test.name)
+		}
+	}
+}
+
+func TestPlaceRejectsUnreachable(t *testing.T) {
+	g := emptyBoardGame(0)
+	g.rng = rand.New(rand.NewSource(1))
+	g.spawn()
+	for x := 0; x < boardWidth-1; x++ {
+		g.Board[boardHeight-2][x] = garbageCell
+	}
+	for x := 4; x < boardWidth; x++ {
+		g.Board[boardHeight-1][x] = garbageCell
+	}
+	if g.collides(0, boardHeight-2, 0) || !g.collides(0, boardHeight-1, 0) {
+		t.Fatal("test board does not leave a grounded gap under the overhang")
+	}
+	if _, ok := g.Place(0, boardHeight-2, 0, false); ok {
+		t.Fatal("placed a piece under the overhang")
+	}
+	if _, ok := g.Place(0, boardHeight-4, 0, false); !ok {
+		t.Fatal("rejected a reachable placement")
+	}
+}
+
+// tSpinDoubleGame leaves a T slot under an overhang that only a rotation
+// can get into.
+func tSpinDoubleGame() Game {
+	g := emptyBoardGame(2)
+	g.rng = rand.New(rand.NewSource(1))
+	g.spawn()
+	for x := 3; x < boardWidth; x++ {
+		g.Board[boardHeight-2][x] = garbageCell
+	}
+	for x := 0; x < boardWidth; x++ {
+		if x != 1 {
+			g.Board[boardHeight-1][x] = garbageCell
+		}
+	}
+	g.Board[boardHeight-3][2] = garbageCell
+	return g
+}
+
+func TestPlaceSpin(t *testing.T) {
+	g := tSpinDoubleGame()
+	result, ok := g.Place(0, boardHeight-3, 2, true)
+	if !ok || !result.TSpin || result.Cleared != 2 {
+		t.Fatalf("spin placement = %+v, %v; want a T-spin double", result, ok)
+	}
+	g = tSpinDoubleGame()
+	if result, ok := g.Place(0, boardHeight-3, 2, false); !ok || result.TSpin {
+		t.Fatalf("placement without spin = %+v, %v; want no T-spin", result, ok)
+	}
+	g = emptyBoardGame(2)
+	g.rng = rand.New(rand.NewSource(1))
+	g.spawn()
+	if _, ok := g.Place(3, boardHeight-2, 0, true); ok {
+		t.Fatal("accepted a spin into a spot only a drop reaches")
+	}
+}
+
+func TestReplayKeepsSpin(t *testing.T) {
+	g := playBot(5, 12)
+	reach := g.reachable(g.Current)
+	spun := false
+	for y := boardHeight - 1; y >= 0 && !spun; y-- {
+		for x := -2; x < boardWidth && !spun; x++ {
+			for rotation := 0; rotation < 4 && !spun; rotation++ {
+				if reach.has(x, y, rotation, true) && g.collides(x, y+1, rotation) {
+					_, spun = g.Place(x, y, rotation, true)
+				}
+			}
+		}
+	}
+	if !spun {
+		t.Fatal("found no spin placement")
+	}
+	g.ResolveLineClear()
+	if err := verifySubmission(newUploadScore(replayEntry(g))); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var resultsItems = []string{
+	"Save score",
+	"Retry",
+	"Menu",
+}
+
+func (m *Model) showResults() tea.Cmd {
+	m.resultsIndex = 0
+	m.historyDone = false
+	m.resultsRank, m.resultsTotal = localRank(m.game.Score)
+	return m.setScreen(screenResults)
+}
+
+func localRank(score int) (int, int) {
+	local, err := loadScores()
+	if err != nil {
+		DebugLogf("results rank load error: %v", err)
+	}
+	rank := 1
+	for _, entry := range local {
+		if entry.Score > score {
+			rank++
+		}
+	}
+	return rank, len(local)
+}
+
+func (m *Model) updateResults(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "up", "k":
+		if m.resultsIndex > 0 {
+			m.resultsIndex--
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "down", "j":
+		if m.resultsIndex < len(resultsItems)-1 {
+			m.resultsIndex++
+			if m.config.Sound {
+				return playSound(m.sound, SoundMenuMove)
+			}
+		}
+	case "enter":
+		var cmd tea.Cmd
+		if m.config.Sound {
+			cmd = playSound(m.sound, SoundMenuSelect)
+		}
+		switch m.resultsIndex {
+		case 0:
+			m.nameInput = activeProfile
+			return tea.Batch(cmd, m.setScreen(screenNameEntry))
+		case 1:
+			m.recordHistory("")
+			if m.battle.active {
+				return tea.Batch(cmd, m.startBattle(m.battle.difficulty))
+			}
+			return tea.Batch(cmd, m.startGame())
+		case 2:
+			m.recordHistory("")
+			return tea.Batch(cmd, m.setScreen(screenMenu))
+		}
+	case "q", "esc":
+		m.recordHistory("")
+		return m.setScreen(screenMenu)
+	}
+	return nil
+}
+
+func viewResults(m Model) string {
+	theme := themes[m.themeIndex]
+	stats := m.game.LiveStats()
+	title := "Game Over"
+	if m.battle.won {
+		title = "You Win"
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle(theme).Render(title))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Score: %d  Lines: %d  Level: %d\n", m.game.Score, m.game.Lines, m.game.Level))
+	b.WriteString(fmt.Sprintf("Time: %s  Pieces: %d\n", formatDuration(stats.Duration()), stats.Pieces))
+	b.WriteString(fmt.Sprintf("PPS: %.2f  KPP: %.2f  APM: %.1f\n", stats.PPS(), stats.KPP(), stats.APM()))
+	b.WriteString(fmt.Sprintf("Max combo: %d  Max B2B: %d  Finesse faults: %d\n", stats.MaxCombo, stats.MaxBackToBack, m.game.Finesse))
+	b.WriteString(fmt.Sprintf("Clears: %d/%d/%d/%d  T-Spins: %d  PCs: %d\n", stats.Singles, stats.Doubles, stats.Triples, stats.Tetrises, stats.TSpins, stats.PerfectClears))
+	b.WriteString("\n")
+	b.WriteString(renderPieceDistribution(stats, theme))
+	b.WriteString("\n\n")
+	rank := fmt.Sprintf("Local rank: #%d of %d", m.resultsRank, m.resultsTotal+1)
+	if m.resultsRank > 50 {
+		rank = "Local rank: outside the top 50"
+	}
+	b.WriteString(highlightStyle(theme).Render(rank))
+	b.WriteString("\n\n")
+	menu := renderMenu("What next?", resultsItems, m.resultsIndex, "Enter to select, Esc for menu", theme)
+	content := lipgloss.JoinVertical(lipgloss.Left, b.String(), menu)
+	return center(m.width, m.height, content)
+}
+
+func renderPieceDistribution(stats GameStats, theme Theme) string {
+	items := make([]string, 0, len(stats.Distribution))
+	for kind, count := range stats.Distribution {
+		label := fmt.Sprintf("%s %d", pieceNames[kind], count)
+		color := theme.PieceColors[kind%len(theme.PieceColors)]
+		items = append(items, lipgloss.NewStyle().Foreground(color).Bold(true).Render(label))
+	}
+	return strings.Join(items, "  ")
+}
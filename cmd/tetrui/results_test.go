@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLocalRank(t *testing.T) {
+	useTempDataDir(t)
+	seedHistory(t,
+		HistoryRecord{Name: "ann", Score: 900},
+		HistoryRecord{Name: "bob", Score: 500},
+		HistoryRecord{Name: "cat", Score: 300},
+	)
+	tests := []struct {
+		score, rank int
+	}{
+		{1000, 1},
+		{600, 2},
+		{500, 2},
+		{100, 4},
+	}
+	for _, test := range tests {
+		if rank, total := localRank(test.score); rank != test.rank || total != 3 {
+			t.Errorf("score %d: rank %d of %d, want %d of 3", test.score, rank, total, test.rank)
+		}
+	}
+}
+
+func finishedGameModel(t *testing.T) Model {
+	t.Helper()
+	useTempDataDir(t)
+	seedHistory(t, HistoryRecord{Name: "ann", Score: 900})
+	m := Model{config: defaultConfig(), game: NewGameWithSeed(1)}
+	m.game.HardDrop()
+	m.game.Score = 1200
+	m.game.Over = true
+	m.showResults()
+	return m
+}
+
+func TestResultsChoices(t *testing.T) {
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	tests := []struct {
+		name    string
+		keys    []tea.KeyMsg
+		screen  Screen
+		history int
+	}{
+		{"save", []tea.KeyMsg{enter}, screenNameEntry, 1},
+		{"save then back", []tea.KeyMsg{enter, {Type: tea.KeyEsc}}, screenResults, 1},
+		{"retry", []tea.KeyMsg{down, enter}, screenGame, 2},
+		{"menu", []tea.KeyMsg{down, down, enter}, screenMenu, 2},
+		{"escape", []tea.KeyMsg{{Type: tea.KeyEsc}}, screenMenu, 2},
+	}
+	for _, test := range tests {
+		m := finishedGameModel(t)
+		if m.screen != screenResults || m.resultsRank != 1 || m.resultsTotal != 1 {
+			t.Fatalf("%s: screen %v, rank %d of %d", test.name, m.screen, m.resultsRank, m.resultsTotal)
+		}
+		for _, key := range test.keys {
+			if m.screen == screenNameEntry {
+				m.updateNameEntry(key)
+			} else {
+				m.updateResults(key)
+			}
+		}
+		records, err := loadHistory()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.screen != test.screen || len(records) != test.history {
+			t.Errorf("%s: screen %v with %d history records, want %v and %d", test.name, m.screen, len(records), test.screen, test.history)
+		}
+	}
+}
+
+func TestResultsRecordOnce(t *testing.T) {
+	m := finishedGameModel(t)
+	m.recordHistory("")
+	m.updateResults(tea.KeyMsg{Type: tea.KeyEsc})
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("%d history records, want the seeded one and this game", len(records))
+	}
+}
+
+func TestViewResults(t *testing.T) {
+	m := finishedGameModel(t)
+	view := viewResults(m)
+	for _, want := range []string{"Game Over", "Score: 1200", "Pieces: 1", "Local rank: #1 of 2", "Save score", "Retry"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("results view is missing %q", want)
+		}
+	}
+	m.battle.won = true
+	if view := viewResults(m); !strings.Contains(view, "You Win") {
+		t.Error("a won battle is not shown as a win")
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var scoresCSVHeader = []string{"name", "score", "lines", "level", "when", "mode", "id"}
+
+func runScores(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: tetrui scores list|export|import|prune|verify|health [flags]")
+		return 2
+	}
+	action := args[0]
+	flags := flag.NewFlagSet("scores "+action, flag.ContinueOnError)
+	remote := flags.Bool("remote", false, "use the configured score backend instead of local scores (not for prune)")
+	profile := flags.String("profile", "", "use this profile's local scores")
+	portable := flags.Bool("portable", false, "use the portable data dir next to the binary")
+	mode := flags.String("mode", "", "only scores from this mode (marathon, battle)")
+	search := flags.String("search", "", "only scores whose name contains this text")
+	window := flags.String("window", "all", "only scores from this window: day, week or all")
+	limit := flags.Int("limit", 0, "show at most this many scores (0 for all)")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+	format := flags.String("format", "json", "export format: csv or json")
+	before := flags.String("before", "", "prune scores older than this date (YYYY-MM-DD)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if action == "prune" && *remote {
+		fmt.Fprintln(os.Stderr, "scores: prune only works on local scores; the score API cannot delete")
+		return 2
+	}
+	if *profile != "" {
+		if !validProfileName(*profile) {
+			fmt.Fprintf(os.Stderr, "scores: invalid profile name %q\n", *profile)
+			return 2
+		}
+		SetProfile(*profile)
+	}
+	SetPortable(*portable)
+	query := ScoreQuery{Mode: *mode, Search: *search, Limit: *limit}
+	switch *window {
+	case "all":
+	case scoreWindowDay, scoreWindowWeek:
+		query.Window = *window
+	default:
+		fmt.Fprintf(os.Stderr, "scores: --window %q: use day, week or all\n", *window)
+		return 2
+	}
+	var sync *ScoreSync
+	if *remote {
+		config, issues, _ := loadConfig()
+		printConfigIssues(issues)
+		if _, err := NewScoreBackend(config); err != nil {
+			fmt.Fprintf(os.Stderr, "scores: --remote: %v\n", err)
+			return 2
+		}
+		config.Sync = true
+		sync = NewScoreSync(config)
+	}
+	var err error
+	switch action {
+	case "list":
+		err = listScores(sync, query, *asJSON)
+	case "export":
+		err = exportScores(sync, query, *format, os.Stdout)
+	case "import":
+		if flags.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "usage: tetrui scores import [flags] <file>")
+			return 2
+		}
+		err = importScores(sync, flags.Arg(0))
+	case "prune":
+		err = pruneScores(*before)
+	case "verify":
+		err = verifyScores(flags.Arg(0))
+	case "health":
+		if sync == nil {
+			fmt.Fprintln(os.Stderr, "scores: health needs --remote")
+			return 2
+		}
+		if err = sync.Health(); err == nil {
+			fmt.Println("ok")
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "scores: unknown action %q\n", action)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "scores %s: %v\n", action, err)
+		return 1
+	}
+	return 0
+}
+
+func fetchScoreList(sync *ScoreSync, query ScoreQuery) ([]ScoreEntry, error) {
+	if sync != nil {
+		scores, err := sync.FetchScores(query)
+		if err != nil {
+			return nil, err
+		}
+		return dedupeScores(scores, nil), nil
+	}
+	scores, err := loadAllScores()
+	if err != nil {
+		return nil, err
+	}
+	return filterScores(scores, query, time.Now()), nil
+}
+
+func listScores(sync *ScoreSync, query ScoreQuery, asJSON bool) error {
+	scores, err := fetchScoreList(sync, query)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeScoresJSON(os.Stdout, scores)
+	}
+	fmt.Printf("%4s  %-12s  %8s  %5s  %5s  %-8s  %s\n", "rank", "name", "score", "lines", "level", "mode", "when")
+	for i, entry := range scores {
+		fmt.Printf("%4d  %-12s  %8d  %5d  %5d  %-8s  %s\n", i+1, entry.Name, entry.Score, entry.Lines, entry.Level, entry.Mode, formatScoreTime(entry.When))
+	}
+	return nil
+}
+
+func exportScores(sync *ScoreSync, query ScoreQuery, format string, out io.Writer) error {
+	scores, err := fetchScoreList(sync, query)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		return writeScoresJSON(out, withReplays(scores))
+	case "csv":
+		writer := csv.NewWriter(out)
+		if err := writer.Write(scoresCSVHeader); err != nil {
+			return err
+		}
+		for _, entry := range scores {
+			record := []string{entry.Name, strconv.Itoa(entry.Score), strconv.Itoa(entry.Lines), strconv.Itoa(entry.Level), entry.When, entry.Mode, entry.ID}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown format %q (use csv or json)", format)
+	}
+}
+
+func writeScoresJSON(out io.Writer, scores []ScoreEntry) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(scores)
+}
+
+func readScoresFile(path string) ([]ScoreEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseScoresCSV(data)
+	}
+	var scores []ScoreEntry
+	if err := json.Unmarshal(data, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+func parseScoresCSV(data []byte) ([]ScoreEntry, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []ScoreEntry{}, nil
+	}
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"name", "score"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv is missing the %q column", name)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	number := func(row []string, name string) (int, error) {
+		text := field(row, name)
+		if text == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(text)
+	}
+	scores := make([]ScoreEntry, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		entry := ScoreEntry{ID: field(row, "id"), Name: field(row, "name"), When: field(row, "when"), Mode: field(row, "mode")}
+		for name, value := range map[string]*int{"score": &entry.Score, "lines": &entry.Lines, "level": &entry.Level} {
+			parsed, err := number(row, name)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d: %s: %v", line+2, name, err)
+			}
+			*value = parsed
+		}
+		scores = append(scores, entry)
+	}
+	return scores, nil
+}
+
+func importScores(sync *ScoreSync, path string) error {
+	incoming, err := readScoresFile(path)
+	if err != nil {
+		return err
+	}
+	existing, err := fetchScoreList(sync, ScoreQuery{})
+	if err != nil {
+		return err
+	}
+	seen := newScoreIndex(existing)
+	added := 0
+	for _, entry := range dedupeScores(incoming, nil) {
+		if entry.Name == "" || seen.has(entry) {
+			continue
+		}
+		if entry.ID == "" {
+			entry.ID = newScoreID()
+		}
+		if sync != nil {
+			err = sync.UploadScore(entry)
+		} else {
+			if entry.Stats == nil && (entry.Pieces > 0 || entry.MaxCombo > 0) {
+				entry.Stats = &GameStats{Pieces: entry.Pieces, MaxCombo: entry.MaxCombo, DurationMs: entry.DurationMs}
+			}
+			err = appendHistory(HistoryRecord{
+				ID:         entry.ID,
+				Name:       entry.Name,
+				Score:      entry.Score,
+				Lines:      entry.Lines,
+				Level:      entry.Level,
+				When:       entry.When,
+				Mode:       entry.Mode,
+				RuleSet:    entry.RuleSet,
+				Seed:       entry.Seed,
+				Stats:      entry.Stats,
+				Replay:     entry.Replay,
+				DurationMs: entry.DurationMs,
+				Version:    entry.Version,
+				Platform:   entry.Platform,
+				Board:      entry.Board,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("after %d new scores: %v", added, err)
+		}
+		added++
+	}
+	fmt.Printf("imported %d new scores, skipped %d\n", added, len(incoming)-added)
+	return nil
+}
+
+func verifyScores(path string) error {
+	var scores []ScoreEntry
+	var err error
+	if path != "" {
+		scores, err = readScoresFile(path)
+	} else {
+		scores, err = loadAllScores()
+		scores = withReplays(scores)
+	}
+	if err != nil {
+		return err
+	}
+	checked, failed := 0, 0
+	for _, entry := range scores {
+		if entry.Replay == "" {
+			continue
+		}
+		checked++
+		if err := verifySubmission(newUploadScore(entry)); err != nil {
+			failed++
+			fmt.Printf("FAIL  %-12s  %8d  %s  %v\n", entry.Name, entry.Score, formatScoreTime(entry.When), err)
+		}
+	}
+	fmt.Printf("verified %d scores with replays, %d failed, %d without replay\n", checked, failed, len(scores)-checked)
+	if failed > 0 {
+		return fmt.Errorf("%d scores do not match their replay", failed)
+	}
+	return nil
+}
+
+func pruneScores(before string) error {
+	if before == "" {
+		return errors.New("--before is required")
+	}
+	cutoff, err := time.ParseInLocation("2006-01-02", before, time.Local)
+	if err != nil {
+		return fmt.Errorf("--before %q: use YYYY-MM-DD", before)
+	}
+	keep := func(when string) bool {
+		played, err := parseScoreTime(when)
+		return err != nil || !played.Before(cutoff)
+	}
+	if err := importLegacyScores(); err != nil {
+		return err
+	}
+	removed, err := pruneHistory(func(record HistoryRecord) bool {
+		return keep(record.When)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pruned %d entries from before %s\n", removed, before)
+	return nil
+}
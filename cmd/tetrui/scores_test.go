@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func seedHistory(t *testing.T, records ...HistoryRecord) {
+	t.Helper()
+	for _, record := range records {
+		if record.ID == "" {
+			record.ID = newScoreID()
+		}
+		if err := appendHistory(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScoresExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{"csv", "json"} {
+		useTempDataDir(t)
+		seedHistory(t,
+			HistoryRecord{Name: "ann", Score: 900, Lines: 12, Level: 2, When: "2024-01-01 10:00"},
+			HistoryRecord{Name: "bob", Score: 500, When: "2024-01-02 10:00", Mode: gameModeBattle},
+		)
+		var out bytes.Buffer
+		if err := exportScores(nil, ScoreQuery{}, format, &out); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "scores."+format)
+		if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		useTempDataDir(t)
+		seedHistory(t, HistoryRecord{Name: "cat", Score: 100, When: "2024-01-03 10:00"})
+		for _, want := range []string{"imported 2 new scores, skipped 0\n", "imported 0 new scores, skipped 2\n"} {
+			var err error
+			got := captureStdout(t, func() { err = importScores(nil, path) })
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if got != want {
+				t.Fatalf("%s: printed %q, want %q", format, got, want)
+			}
+		}
+		scores, err := loadAllScores()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(scores) != 3 || scores[0].Name != "ann" || scores[0].Lines != 12 || scores[1].Mode != gameModeBattle {
+			t.Fatalf("%s: imported %+v", format, scores)
+		}
+	}
+}
+
+func TestScoresImportRemote(t *testing.T) {
+	useTempDataDir(t)
+	path := filepath.Join(t.TempDir(), "scores.csv")
+	data := "name,score,when\nann,900,2024-01-01 10:00\nbob,500,2024-01-02 10:00\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	backend := &memoryBackend{scores: []ScoreEntry{{ID: newScoreID(), Name: "ann", Score: 900, When: "2024-01-01 10:00"}}}
+	sync := &ScoreSync{enabled: true, backend: backend}
+	captureStdout(t, func() {
+		if err := importScores(sync, path); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if len(backend.scores) != 2 || backend.scores[1].Name != "bob" || backend.scores[1].ID == "" {
+		t.Fatalf("remote scores %+v", backend.scores)
+	}
+	if records, _ := loadHistory(); len(records) != 0 {
+		t.Fatalf("a remote import wrote local history: %+v", records)
+	}
+}
+
+func TestParseScoresCSVErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"name,lines\nann,3\n", `missing the "score" column`},
+		{"name,score\nann,900\nbob,lots\n", "csv line 3: score"},
+	}
+	for _, test := range tests {
+		if _, err := parseScoresCSV([]byte(test.data)); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: error %v, want %q", test.data, err, test.want)
+		}
+	}
+}
+
+func TestScoresListFilters(t *testing.T) {
+	useTempDataDir(t)
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	seedHistory(t,
+		HistoryRecord{Name: "ann", Score: 900, When: recent},
+		HistoryRecord{Name: "bob", Score: 500, When: recent, Mode: gameModeBattle},
+		HistoryRecord{Name: "annie", Score: 300, When: "2020-01-01 10:00"},
+	)
+	scores, err := fetchScoreList(nil, ScoreQuery{Search: "ann", Window: scoreWindowWeek})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 || scores[0].Name != "ann" {
+		t.Fatalf("listed %+v", scores)
+	}
+	if code := runScores([]string{"list", "--window", "month"}); code != 2 {
+		t.Fatalf("--window month: exit %d, want 2", code)
+	}
+}
+
+func TestPruneScores(t *testing.T) {
+	useTempDataDir(t)
+	seedHistory(t,
+		HistoryRecord{Name: "old", Score: 10, When: "2020-01-01 10:00"},
+		HistoryRecord{Name: "new", Score: 20, When: "2024-06-01T10:00:00Z"},
+		HistoryRecord{Name: "odd", Score: 30, When: "someday"},
+	)
+	for _, before := range []string{"", "01/01/2024"} {
+		if err := pruneScores(before); err == nil {
+			t.Errorf("--before %q was accepted", before)
+		}
+	}
+	got := captureStdout(t, func() {
+		if err := pruneScores("2024-01-01"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got != "pruned 1 entries from before 2024-01-01\n" {
+		t.Fatalf("printed %q", got)
+	}
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "new" || records[1].Name != "odd" {
+		t.Fatalf("kept %+v", records)
+	}
+}
+
+func TestScoresPruneRejectsRemote(t *testing.T) {
+	useTempDataDir(t)
+	seedHistory(t, HistoryRecord{Name: "old", Score: 10, When: "2020-01-01 10:00"})
+	if code := runScores([]string{"prune", "--remote", "--before", "2024-01-01"}); code != 2 {
+		t.Fatalf("exit code %d, want 2", code)
+	}
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("prune --remote removed local scores: %+v", records)
+	}
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	serverNameLimit  = 32
+	serverPageLimit  = 500
+	serverBodyLimit  = 8 << 20
+	serverRateWindow = time.Minute
+	serverBackdate   = 24 * time.Hour
+)
+
+type serverScore struct {
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name"`
+	Score      int    `json:"score"`
+	Lines      int    `json:"lines"`
+	Level      int    `json:"level"`
+	Mode       string `json:"mode,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	InputHash  string `json:"input_hash,omitempty"`
+	Seed       int64  `json:"seed,omitempty"`
+	RuleSet    string `json:"rule_set,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Pieces     int    `json:"pieces,omitempty"`
+	MaxCombo   int    `json:"max_combo,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Board      string `json:"board,omitempty"`
+}
+
+func (s serverScore) apiScore() apiScore {
+	return apiScore{
+		ID:         s.ID,
+		Name:       s.Name,
+		Score:      s.Score,
+		Lines:      s.Lines,
+		Level:      s.Level,
+		Mode:       s.Mode,
+		CreatedAt:  s.CreatedAt,
+		Seed:       s.Seed,
+		RuleSet:    s.RuleSet,
+		DurationMs: s.DurationMs,
+		Pieces:     s.Pieces,
+		MaxCombo:   s.MaxCombo,
+		Version:    s.Version,
+		Platform:   s.Platform,
+		Board:      s.Board,
+	}
+}
+
+type scoreServer struct {
+	db      string
+	apiKey  string
+	secret  string
+	verify  bool
+	rate    int
+	mu      sync.Mutex
+	scores  []serverScore
+	clients map[string]*rateWindow
+	now     func() time.Time
+	subMu   sync.Mutex
+	subs    map[chan apiScore]struct{}
+	done    chan struct{}
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func runScoreServer(args []string) int {
+	flags := flag.NewFlagSet("score-server", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "listen address")
+	db := flags.String("db", "scores.db", "JSON file the scores are stored in")
+	apiKey := flags.String("key", os.Getenv("TETRUI_SCORE_API_KEY"), "required X-Api-Key value (empty allows anyone)")
+	secret := flags.String("secret", os.Getenv("TETRUI_SCORE_SECRET"), "reject submissions not signed with this HMAC secret (empty allows unsigned)")
+	verify := flags.Bool("verify", true, "re-simulate each submission's replay and reject scores it does not reproduce (--verify=false accepts any well-formed score)")
+	rate := flags.Int("rate", 10, "score submissions allowed per client per minute (0 for no limit)")
+	debug := flags.Bool("debug", false, "enable debug logging")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	EnableDebugLogging(*debug)
+	server, err := newScoreServer(*db, *apiKey, *rate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "score-server: %v\n", err)
+		return 1
+	}
+	server.secret = *secret
+	server.verify = *verify
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "score-server: %v\n", err)
+		return 1
+	}
+	httpServer := &http.Server{Handler: server.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	httpServer.RegisterOnShutdown(server.Close)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdown)
+	}()
+	fmt.Printf("score server listening on %s (%d scores in %s)\n", listener.Addr(), len(server.scores), *db)
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "score-server: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func newScoreServer(db, apiKey string, rate int) (*scoreServer, error) {
+	server := &scoreServer{
+		db:      db,
+		apiKey:  apiKey,
+		rate:    rate,
+		scores:  []serverScore{},
+		clients: make(map[string]*rateWindow),
+		now:     time.Now,
+		verify:  true,
+		subs:    make(map[chan apiScore]struct{}),
+		done:    make(chan struct{}),
+	}
+	if _, err := readJSONFile(db, &server.scores); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("load %s: %v", db, err)
+	}
+	sortServerScores(server.scores)
+	return server, nil
+}
+
+func (s *scoreServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleList)
+	mux.HandleFunc("POST /{$}", s.handleSubmit)
+	mux.HandleFunc("GET /boards/{mode}", s.handleList)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	return s.checkKey(mux)
+}
+
+func (s *scoreServer) checkKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.apiKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Api-Key")), []byte(s.apiKey)) != 1 {
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *scoreServer) handleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	mode := r.PathValue("mode")
+	if mode == "" {
+		mode = query.Get("mode")
+	}
+	offset, err := queryInt(query.Get("offset"), 0)
+	if err != nil {
+		http.Error(w, "offset: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(query.Get("limit"), 0)
+	if err != nil {
+		http.Error(w, "limit: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit > serverPageLimit {
+		limit = serverPageLimit
+	}
+	var since time.Time
+	if text := query.Get("since"); text != "" {
+		if since, err = time.Parse(time.RFC3339, text); err != nil {
+			http.Error(w, "since: use an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+	}
+	search := strings.ToLower(query.Get("q"))
+	s.mu.Lock()
+	board := make([]apiScore, 0, len(s.scores))
+	for _, score := range s.scores {
+		if mode != "" && serverScoreMode(score) != mode {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(score.Name), search) {
+			continue
+		}
+		if !since.IsZero() {
+			created, err := time.Parse(time.RFC3339, score.CreatedAt)
+			if err != nil || created.Before(since) {
+				continue
+			}
+		}
+		board = append(board, score.apiScore())
+	}
+	s.mu.Unlock()
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(board)))
+	if offset > len(board) {
+		offset = len(board)
+	}
+	board = board[offset:]
+	if limit > 0 && len(board) > limit {
+		board = board[:limit]
+	}
+	writeServerJSON(w, http.StatusOK, board)
+}
+
+func (s *scoreServer) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if wait := s.allow(clientAddr(r)); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+0.5)))
+		http.Error(w, "too many submissions", http.StatusTooManyRequests)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, serverBodyLimit))
+	if err != nil {
+		http.Error(w, "bad score: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.secret != "" && !validSignature(s.secret, body, r.Header.Get(signatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var upload uploadScore
+	if err := json.Unmarshal(body, &upload); err != nil {
+		http.Error(w, "bad score: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	upload.Name = strings.TrimSpace(upload.Name)
+	switch {
+	case upload.Name == "" || len(upload.Name) > serverNameLimit:
+		http.Error(w, fmt.Sprintf("name must be 1-%d characters", serverNameLimit), http.StatusBadRequest)
+		return
+	case upload.Score < 0 || upload.Lines < 0 || upload.Level < 0:
+		http.Error(w, "score, lines and level must not be negative", http.StatusBadRequest)
+		return
+	case len(upload.ID) > serverNameLimit*2:
+		http.Error(w, "id is too long", http.StatusBadRequest)
+		return
+	}
+	if existing, ok := s.find(upload.ID); ok {
+		writeServerJSON(w, http.StatusOK, existing.apiScore())
+		return
+	}
+	if s.verify {
+		if err := verifySubmission(upload); err != nil {
+			DebugLogf("score server rejected name=%s score=%d: %v", upload.Name, upload.Score, err)
+			http.Error(w, "score rejected: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	now := s.now()
+	created := now
+	// Only signed uploads may say when they were played, and only recently,
+	// so nobody can move a score out of the day and week boards.
+	if played, err := time.Parse(time.RFC3339, upload.PlayedAt); err == nil && s.secret != "" &&
+		!played.After(now.Add(time.Minute)) && now.Sub(played) <= serverBackdate {
+		created = played
+	}
+	score := serverScore{
+		ID:         upload.ID,
+		Name:       upload.Name,
+		Score:      upload.Score,
+		Lines:      upload.Lines,
+		Level:      upload.Level,
+		Mode:       upload.Mode,
+		CreatedAt:  created.UTC().Format(time.RFC3339),
+		InputHash:  upload.InputHash,
+		Seed:       upload.Seed,
+		RuleSet:    upload.RuleSet,
+		DurationMs: upload.DurationMs,
+		Pieces:     upload.Pieces,
+		MaxCombo:   upload.MaxCombo,
+		Version:    upload.Version,
+		Platform:   upload.Platform,
+		Board:      upload.Board,
+	}
+	if err := s.insert(score); err != nil {
+		DebugLogf("score server save error: %v", err)
+		http.Error(w, "could not save score", http.StatusInternalServerError)
+		return
+	}
+	DebugLogf("score server stored name=%s score=%d mode=%s", score.Name, score.Score, score.Mode)
+	s.broadcast(score.apiScore())
+	writeServerJSON(w, http.StatusCreated, score.apiScore())
+}
+
+func (s *scoreServer) find(id string) (serverScore, bool) {
+	if id == "" {
+		return serverScore{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, score := range s.scores {
+		if score.ID == id {
+			return score, true
+		}
+	}
+	return serverScore{}, false
+}
+
+func (s *scoreServer) insert(score serverScore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if score.ID != "" {
+		for _, existing := range s.scores {
+			if existing.ID == score.ID {
+				return nil
+			}
+		}
+	}
+	scores := append(append([]serverScore{}, s.scores...), score)
+	sortServerScores(scores)
+	data, err := json.MarshalIndent(scores, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.db, data); err != nil {
+		return err
+	}
+	s.scores = scores
+	return nil
+}
+
+func (s *scoreServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	events := make(chan apiScore, 16)
+	s.subMu.Lock()
+	s.subs[events] = struct{}{}
+	s.subMu.Unlock()
+	defer func() {
+		s.subMu.Lock()
+		delete(s.subs, events)
+		s.subMu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	ping := time.NewTicker(livePing)
+	defer ping.Stop()
+	for {
+		select {
+		case score := <-events:
+			data, err := json.Marshal(score)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: score\ndata: %s\n\n", data)
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *scoreServer) broadcast(score apiScore) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for events := range s.subs {
+		select {
+		case events <- score:
+		default:
+			DebugLogf("score server dropped event for a slow subscriber")
+		}
+	}
+}
+
+func (s *scoreServer) Close() {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+func (s *scoreServer) allow(client string) time.Duration {
+	if s.rate <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for addr, window := range s.clients {
+		if now.Sub(window.start) >= serverRateWindow {
+			delete(s.clients, addr)
+		}
+	}
+	window, ok := s.clients[client]
+	if !ok {
+		window = &rateWindow{start: now}
+		s.clients[client] = window
+	}
+	if window.count >= s.rate {
+		return serverRateWindow - now.Sub(window.start)
+	}
+	window.count++
+	return 0
+}
+
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func serverScoreMode(score serverScore) string {
+	if score.Mode == "" {
+		return gameModeMarathon
+	}
+	return score.Mode
+}
+
+func sortServerScores(scores []serverScore) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].CreatedAt < scores[j].CreatedAt
+	})
+}
+
+func queryInt(text string, fallback int) (int, error) {
+	if text == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative number", text)
+	}
+	return value, nil
+}
+
+func writeServerJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		DebugLogf("score server write error: %v", err)
+	}
+}
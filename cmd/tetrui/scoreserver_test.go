@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestScoreServer skips replay checks, since most test scores are made up.
+func newTestScoreServer(t *testing.T, apiKey string) *scoreServer {
+	t.Helper()
+	server, err := newScoreServer(filepath.Join(t.TempDir(), "scores.json"), apiKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.verify = false
+	return server
+}
+
+func TestScoreServerPlayedAt(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		secret string
+		played time.Time
+		want   time.Time
+	}{
+		{"signed recent", "s3", now.Add(-2 * time.Hour), now.Add(-2 * time.Hour)},
+		{"signed backdated", "s3", now.Add(-72 * time.Hour), now},
+		{"signed future", "s3", now.Add(time.Hour), now},
+		{"unsigned", "", now.Add(-2 * time.Hour), now},
+	}
+	for _, test := range tests {
+		server := newTestScoreServer(t, "")
+		server.secret = test.secret
+		server.now = func() time.Time { return now }
+		stub := httptest.NewServer(server.Handler())
+		backend := &webhookBackend{baseURL: stub.URL, secret: test.secret, client: stub.Client()}
+		entry := ScoreEntry{ID: newScoreID(), Name: "ann", Score: 100, When: test.played.Format(time.RFC3339)}
+		if err := backend.Submit(entry); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		stub.Close()
+		if got := server.scores[0].CreatedAt; got != test.want.Format(time.RFC3339) {
+			t.Errorf("%s: created_at %s, want %s", test.name, got, test.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestScoreServerVerifiesByDefault(t *testing.T) {
+	server, err := newScoreServer(filepath.Join(t.TempDir(), "scores.json"), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := httptest.NewServer(server.Handler())
+	defer stub.Close()
+	backend := &webhookBackend{baseURL: stub.URL, client: stub.Client()}
+	forged := ScoreEntry{ID: newScoreID(), Name: "eve", Score: 999999, Seed: 1, RuleSet: ruleSet}
+	if err := backend.Submit(forged); !scoreRejected(err) {
+		t.Fatalf("forged score: %v, want it rejected", err)
+	}
+	genuine := replayEntry(playBot(2, 30))
+	genuine.ID = newScoreID()
+	if err := backend.Submit(genuine); err != nil {
+		t.Fatalf("genuine score: %v", err)
+	}
+	if len(server.scores) != 1 || server.scores[0].Score != genuine.Score {
+		t.Fatalf("stored %+v", server.scores)
+	}
+}
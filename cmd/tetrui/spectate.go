@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const spectatorQueueSize = 8
+
+type spectatorFrame struct {
+	Game  Game   `json:"game"`
+	Theme string `json:"theme"`
+}
+
+type SpectatorHub struct {
+	listener net.Listener
+	mu       sync.Mutex
+	clients  map[net.Conn]chan []byte
+	last     []byte
+}
+
+func StartSpectatorHub(addr string) (*SpectatorHub, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	hub := &SpectatorHub{
+		listener: listener,
+		clients:  make(map[net.Conn]chan []byte),
+	}
+	DebugLogf("spectator hub listening addr=%s", listener.Addr())
+	go hub.acceptLoop()
+	return hub, nil
+}
+
+func (h *SpectatorHub) Addr() string {
+	if h == nil {
+		return ""
+	}
+	return h.listener.Addr().String()
+}
+
+func (h *SpectatorHub) Publish(game Game, theme string) {
+	if h == nil {
+		return
+	}
+	data, err := json.Marshal(spectatorFrame{Game: game, Theme: theme})
+	if err != nil {
+		DebugLogf("spectator encode error: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = data
+	for _, queue := range h.clients {
+		select {
+		case queue <- data:
+		default:
+		}
+	}
+}
+
+func (h *SpectatorHub) Close() {
+	if h == nil {
+		return
+	}
+	_ = h.listener.Close()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn, queue := range h.clients {
+		close(queue)
+		delete(h.clients, conn)
+	}
+}
+
+func (h *SpectatorHub) acceptLoop() {
+	for {
+		conn, err := h.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				DebugLogf("spectator accept error: %v", err)
+			}
+			return
+		}
+		DebugLogf("spectator connected remote=%s", conn.RemoteAddr())
+		queue := make(chan []byte, spectatorQueueSize)
+		h.mu.Lock()
+		h.clients[conn] = queue
+		if h.last != nil {
+			queue <- h.last
+		}
+		h.mu.Unlock()
+		go h.writeLoop(conn, queue)
+	}
+}
+
+func (h *SpectatorHub) writeLoop(conn net.Conn, queue chan []byte) {
+	defer conn.Close()
+	for data := range queue {
+		_ = conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+		if _, err := conn.Write(data); err != nil {
+			DebugLogf("spectator write error remote=%s: %v", conn.RemoteAddr(), err)
+			h.mu.Lock()
+			if _, ok := h.clients[conn]; ok {
+				delete(h.clients, conn)
+				close(queue)
+			}
+			h.mu.Unlock()
+			for range queue {
+			}
+			return
+		}
+	}
+}
+
+type spectatorFrameMsg struct {
+	frame spectatorFrame
+}
+
+type spectatorClosedMsg struct {
+	err error
+}
+
+type watchModel struct {
+	addr    string
+	conn    net.Conn
+	reader  *bufio.Reader
+	width   int
+	height  int
+	frame   spectatorFrame
+	hasGame bool
+	err     error
+}
+
+func runWatch(args []string) int {
+	if len(args) < 1 {
+		fmt.Println("usage: tetrui watch <addr>")
+		return 2
+	}
+	addr := args[0]
+	conn, err := net.DialTimeout("tcp", addr, 4*time.Second)
+	if err != nil {
+		fmt.Printf("watch: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	model := watchModel{
+		addr:   addr,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+	program := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		DebugLogf("watch program error: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func (m watchModel) Init() tea.Cmd {
+	return readSpectatorFrameCmd(m.reader)
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case spectatorFrameMsg:
+		m.frame = msg.frame
+		m.hasGame = true
+		return m, readSpectatorFrameCmd(m.reader)
+	case spectatorClosedMsg:
+		m.err = msg.err
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m watchModel) View() string {
+	theme := themes[0]
+	if index := themeIndexByName(m.frame.Theme); index >= 0 {
+		theme = themes[index]
+	}
+	if !m.hasGame {
+		message := fmt.Sprintf("Waiting for %s...", m.addr)
+		if m.err != nil {
+			message = fmt.Sprintf("Disconnected from %s.", m.addr)
+		}
+		return center(m.width, m.height, message)
+	}
+	content := renderSpectatorGame(m.frame.Game, theme)
+	status := helpStyle(theme).Render(fmt.Sprintf("Watching %s  Q: quit", m.addr))
+	if m.err != nil {
+		status = warningStyle(theme).Render(fmt.Sprintf("Disconnected from %s.  Q: quit", m.addr))
+	}
+	return center(m.width, m.height, lipgloss.JoinVertical(lipgloss.Left, content, "", status))
+}
+
+func renderSpectatorGame(g Game, theme Theme) string {
+	board := renderBoard(g, theme, 1, true, nil, time.Time{}, time.Time{}, nil, nil, time.Time{}, time.Time{}, nil)
+	info := renderInfo(g, theme, 1, "", 0, "", defaultConfig().keyHelp())
+	return lipgloss.JoinHorizontal(lipgloss.Top, board, info)
+}
+
+func readSpectatorFrameCmd(reader *bufio.Reader) tea.Cmd {
+	return func() tea.Msg {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return spectatorClosedMsg{err: err}
+		}
+		var frame spectatorFrame
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &frame); err != nil {
+			DebugLogf("spectator decode error: %v", err)
+			return spectatorClosedMsg{err: err}
+		}
+		if !validSpectatorGame(frame.Game) {
+			return spectatorClosedMsg{err: errors.New("invalid spectator frame")}
+		}
+		return spectatorFrameMsg{frame: frame}
+	}
+}
+
+func validSpectatorGame(g Game) bool {
+	if len(g.Board) != boardHeight {
+		return false
+	}
+	for _, row := range g.Board {
+		if len(row) != boardWidth {
+			return false
+		}
+		for _, cell := range row {
+			if cell < 0 || cell > garbageCell {
+				return false
+			}
+		}
+	}
+	if g.Current < 0 || g.Current >= len(pieceRotations) || g.Next < 0 || g.Next >= len(pieceRotations) {
+		return false
+	}
+	if g.HasHold && (g.HoldKind < 0 || g.HoldKind >= len(pieceRotations)) {
+		return false
+	}
+	return g.Rotation >= 0 && g.Rotation < 4
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func readFrame(t *testing.T, reader *bufio.Reader) spectatorFrame {
+	t.Helper()
+	msg := readSpectatorFrameCmd(reader)()
+	frame, ok := msg.(spectatorFrameMsg)
+	if !ok {
+		t.Fatalf("read %#v, want a frame", msg)
+	}
+	return frame.frame
+}
+
+func TestSpectatorHubStreamsFrames(t *testing.T) {
+	hub, err := StartSpectatorHub("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hub.Close()
+	game := NewGameWithSeed(1)
+	game.Score = 100
+	hub.Publish(game, themes[1].Name)
+
+	conn, err := net.Dial("tcp", hub.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	if frame := readFrame(t, reader); frame.Game.Score != 100 || frame.Theme != themes[1].Name {
+		t.Fatalf("late joiner got score %d theme %q, want the last frame", frame.Game.Score, frame.Theme)
+	}
+	game.HardDrop()
+	game.Score = 250
+	hub.Publish(game, themes[1].Name)
+	frame := readFrame(t, reader)
+	if frame.Game.Score != 250 || frame.Game.Stats.Pieces != 1 {
+		t.Fatalf("got score %d pieces %d", frame.Game.Score, frame.Game.Stats.Pieces)
+	}
+	if frame.Game.Board[boardHeight-1] == nil || !validSpectatorGame(frame.Game) {
+		t.Fatal("frame board did not survive the stream")
+	}
+}
+
+func TestPublishSpectatorOnlyInGame(t *testing.T) {
+	hub, err := StartSpectatorHub("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hub.Close()
+	published := func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return hub.last != nil
+	}
+	m := Model{screen: screenMenu, game: NewGameWithSeed(1), spectate: hub}
+	m.publishSpectator()
+	if published() {
+		t.Fatal("published a frame from the menu")
+	}
+	m.screen = screenGame
+	m.publishSpectator()
+	if !published() {
+		t.Fatal("no frame published during a game")
+	}
+}
+
+func TestValidSpectatorGame(t *testing.T) {
+	valid := NewGameWithSeed(1)
+	if !validSpectatorGame(valid) {
+		t.Fatal("rejected a new game")
+	}
+	tests := map[string]func(g *Game){
+		"short board": func(g *Game) { g.Board = g.Board[1:] },
+		"bad cell":    func(g *Game) { g.Board[0][0] = garbageCell + 1 },
+		"bad piece":   func(g *Game) { g.Current = len(pieceRotations) },
+		"bad hold":    func(g *Game) { g.HasHold, g.HoldKind = true, -1 },
+		"bad turn":    func(g *Game) { g.Rotation = 4 },
+	}
+	for name, corrupt := range tests {
+		g := NewGameWithSeed(1)
+		corrupt(&g)
+		if validSpectatorGame(g) {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
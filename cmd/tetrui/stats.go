@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type GameStats struct {
+	Pieces        int    `json:"pieces"`
+	Keys          int    `json:"keys"`
+	Attack        int    `json:"attack"`
+	DurationMs    int64  `json:"duration_ms"`
+	Singles       int    `json:"singles"`
+	Doubles       int    `json:"doubles"`
+	Triples       int    `json:"triples"`
+	Tetrises      int    `json:"tetrises"`
+	TSpins        int    `json:"tspins"`
+	PerfectClears int    `json:"perfect_clears"`
+	MaxCombo      int    `json:"max_combo"`
+	MaxBackToBack int    `json:"max_back_to_back"`
+	Distribution  [7]int `json:"distribution"`
+}
+
+func (s GameStats) Duration() time.Duration {
+	return time.Duration(s.DurationMs) * time.Millisecond
+}
+
+func (s GameStats) PPS() float64 {
+	seconds := s.Duration().Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(s.Pieces) / seconds
+}
+
+func (s GameStats) APM() float64 {
+	minutes := s.Duration().Minutes()
+	if minutes <= 0 {
+		return 0
+	}
+	return float64(s.Attack) / minutes
+}
+
+func (s GameStats) KPP() float64 {
+	if s.Pieces == 0 {
+		return 0
+	}
+	return float64(s.Keys) / float64(s.Pieces)
+}
+
+func (s *GameStats) record(kind int, result LockResult) {
+	s.Pieces++
+	if kind >= 0 && kind < len(s.Distribution) {
+		s.Distribution[kind]++
+	}
+	s.Attack += result.Attack
+	if result.Combo > s.MaxCombo {
+		s.MaxCombo = result.Combo
+	}
+	if result.BackToBack > s.MaxBackToBack {
+		s.MaxBackToBack = result.BackToBack
+	}
+	if result.TSpin {
+		s.TSpins++
+	}
+	switch result.Cleared {
+	case 1:
+		s.Singles++
+	case 2:
+		s.Doubles++
+	case 3:
+		s.Triples++
+	case 4:
+		s.Tetrises++
+	}
+	if result.PerfectClear {
+		s.PerfectClears++
+	}
+}
+
+func (g *Game) StartClock() {
+	if g.clockStart.IsZero() {
+		g.clockStart = time.Now()
+	}
+}
+
+func (g *Game) StopClock() {
+	if g.clockStart.IsZero() || !g.clockStop.IsZero() {
+		return
+	}
+	g.clockStop = time.Now()
+	if g.Paused && !g.pausedAt.IsZero() {
+		g.clockStop = g.pausedAt
+	}
+	g.Stats.DurationMs = g.Elapsed().Milliseconds()
+}
+
+func (g *Game) SetPaused(paused bool) {
+	if paused == g.Paused {
+		return
+	}
+	g.Paused = paused
+	if paused {
+		g.pausedAt = time.Now()
+		return
+	}
+	if !g.pausedAt.IsZero() {
+		g.pausedFor += time.Since(g.pausedAt)
+		g.pausedAt = time.Time{}
+	}
+}
+
+func (g *Game) Elapsed() time.Duration {
+	if g.clockStart.IsZero() {
+		return time.Duration(g.Stats.DurationMs) * time.Millisecond
+	}
+	end := time.Now()
+	if !g.clockStop.IsZero() {
+		end = g.clockStop
+	} else if g.Paused && !g.pausedAt.IsZero() {
+		end = g.pausedAt
+	}
+	elapsed := end.Sub(g.clockStart) - g.pausedFor
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+func (g *Game) LiveStats() GameStats {
+	stats := g.Stats
+	stats.DurationMs = g.Elapsed().Milliseconds()
+	return stats
+}
+
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func renderStats(stats GameStats, theme Theme) string {
+	pad := lipgloss.NewStyle().PaddingLeft(2)
+	lines := []string{
+		pad.Render(titleStyle(theme).Render("Stats")),
+		pad.Render(fmt.Sprintf("Time:    %s", formatDuration(stats.Duration()))),
+		pad.Render(fmt.Sprintf("Pieces:  %d", stats.Pieces)),
+		pad.Render(fmt.Sprintf("PPS:     %.2f", stats.PPS())),
+		pad.Render(fmt.Sprintf("APM:     %.1f", stats.APM())),
+		pad.Render(fmt.Sprintf("KPP:     %.2f", stats.KPP())),
+		"",
+		pad.Render(fmt.Sprintf("Singles: %d", stats.Singles)),
+		pad.Render(fmt.Sprintf("Doubles: %d", stats.Doubles)),
+		pad.Render(fmt.Sprintf("Triples: %d", stats.Triples)),
+		pad.Render(fmt.Sprintf("Tetris:  %d", stats.Tetrises)),
+		pad.Render(fmt.Sprintf("T-Spins: %d", stats.TSpins)),
+		pad.Render(fmt.Sprintf("PCs:     %d", stats.PerfectClears)),
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestStatsRecordLockedPiece(t *testing.T) {
+	for _, clear := range []bool{false, true} {
+		g := emptyBoardGame(0)
+		g.rng = rand.New(rand.NewSource(1))
+		g.Next = 2
+		g.spawn()
+		if clear {
+			for x := 4; x < boardWidth; x++ {
+				g.Board[boardHeight-1][x] = garbageCell
+			}
+			g.Move(-1)
+			g.Move(-1)
+			g.Move(-1)
+		}
+		result := g.HardDrop()
+		if clear != (result.Cleared == 1) {
+			t.Fatalf("clear=%v: cleared %d lines", clear, result.Cleared)
+		}
+		want := [7]int{1, 0, 0, 0, 0, 0, 0}
+		if g.Stats.Distribution != want || g.Stats.Pieces != 1 {
+			t.Errorf("clear=%v: distribution %v, pieces %d; want one I", clear, g.Stats.Distribution, g.Stats.Pieces)
+		}
+	}
+}
+
+func TestStatsRecordLineClears(t *testing.T) {
+	var stats GameStats
+	for _, result := range []LockResult{
+		{Cleared: 1},
+		{Cleared: 2, Combo: 1},
+		{Cleared: 3, Combo: 2},
+		{Cleared: 4, Combo: 3, Attack: 4, BackToBack: 1},
+		{Cleared: 2, TSpin: true, Attack: 5, BackToBack: 2},
+		{Cleared: 4, PerfectClear: true, Attack: 10},
+		{},
+	} {
+		stats.record(2, result)
+	}
+	want := GameStats{
+		Pieces:        7,
+		Attack:        19,
+		Singles:       1,
+		Doubles:       2,
+		Triples:       1,
+		Tetrises:      2,
+		TSpins:        1,
+		PerfectClears: 1,
+		MaxCombo:      3,
+		MaxBackToBack: 2,
+		Distribution:  [7]int{0, 0, 7, 0, 0, 0, 0},
+	}
+	if stats != want {
+		t.Fatalf("stats %+v\nwant  %+v", stats, want)
+	}
+}
+
+func TestStatsRates(t *testing.T) {
+	stats := GameStats{Pieces: 30, Keys: 90, Attack: 12, DurationMs: 20000}
+	if got := stats.PPS(); got != 1.5 {
+		t.Errorf("PPS = %v, want 1.5", got)
+	}
+	if got := stats.APM(); got < 35.99 || got > 36.01 {
+		t.Errorf("APM = %v, want 36", got)
+	}
+	if got := stats.KPP(); got != 3 {
+		t.Errorf("KPP = %v, want 3", got)
+	}
+	var empty GameStats
+	if empty.PPS() != 0 || empty.APM() != 0 || empty.KPP() != 0 {
+		t.Errorf("empty stats rates: %v %v %v", empty.PPS(), empty.APM(), empty.KPP())
+	}
+}
+
+func TestStatsCountKeys(t *testing.T) {
+	g := NewGameWithSeed(1)
+	g.Move(-1)
+	g.Move(1)
+	g.Rotate(1)
+	g.SoftDrop()
+	g.Hold()
+	g.HardDrop()
+	if g.Stats.Keys != 6 || g.Stats.Pieces != 1 {
+		t.Fatalf("keys %d, pieces %d; want 6 and 1", g.Stats.Keys, g.Stats.Pieces)
+	}
+}
+
+func TestElapsedSkipsPauses(t *testing.T) {
+	g := NewGameWithSeed(1)
+	now := time.Now()
+	g.clockStart = now.Add(-10 * time.Second)
+	g.clockStop = now
+	g.pausedFor = 4 * time.Second
+	if got := g.LiveStats().Duration(); got != 6*time.Second {
+		t.Fatalf("elapsed %v, want 6s", got)
+	}
+}
+
+func TestStatsSavedWithScore(t *testing.T) {
+	useTempDataDir(t)
+	m := Model{game: NewGameWithSeed(1)}
+	m.game.HardDrop()
+	m.game.HardDrop()
+	m.recordHistory("ann")
+	scores, err := loadAllScores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 || scores[0].Stats == nil || scores[0].Stats.Pieces != 2 {
+		t.Fatalf("saved scores %+v", scores)
+	}
+}
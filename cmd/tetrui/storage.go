@@ -1,202 +1,380 @@
 package main
 
 import (
-	"bytes"
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
-type Config struct {
-	Theme         string `json:"theme"`
-	Sound         bool   `json:"sound"`
-	Music         bool   `json:"music"`
-	Shadow        bool   `json:"shadow"`
-	Animations    bool   `json:"animations"`
-	HardDropTrace bool   `json:"hard_drop_trace"`
-	Scale         int    `json:"scale"`
-	Sync          bool   `json:"sync"`
-	Volume        int    `json:"volume"`
-}
+const (
+	scoreTimeLayout = "2006-01-02 15:04"
+	lockTimeout     = 2 * time.Second
+)
 
 type ScoreEntry struct {
-	Name  string `json:"name"`
-	Score int    `json:"score"`
-	Lines int    `json:"lines"`
-	Level int    `json:"level"`
-	When  string `json:"when"`
-}
-
-func loadConfig() (Config, error) {
-	config := Config{
-		Theme:         themes[0].Name,
-		Sound:         true,
-		Music:         true,
-		Shadow:        true,
-		Animations:    true,
-		HardDropTrace: true,
-		Scale:         1,
-		Sync:          true,
-		Volume:        70,
-	}
-	path, err := configPath()
-	if err != nil {
-		return config, err
-	}
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return config, nil
-	}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return config, err
-	}
-	if !bytes.Contains(data, []byte("\"sync\"")) {
-		config.Sync = true
+	ID         string     `json:"id,omitempty"`
+	Name       string     `json:"name"`
+	Score      int        `json:"score"`
+	Lines      int        `json:"lines"`
+	Level      int        `json:"level"`
+	When       string     `json:"when"`
+	Mode       string     `json:"mode,omitempty"`
+	Stats      *GameStats `json:"stats,omitempty"`
+	Seed       int64      `json:"seed,omitempty"`
+	RuleSet    string     `json:"rule_set,omitempty"`
+	Replay     string     `json:"replay,omitempty"`
+	DurationMs int64      `json:"duration_ms,omitempty"`
+	Pieces     int        `json:"pieces,omitempty"`
+	MaxCombo   int        `json:"max_combo,omitempty"`
+	Version    string     `json:"version,omitempty"`
+	Platform   string     `json:"platform,omitempty"`
+	Board      string     `json:"board,omitempty"`
+	// ReplayPath points at a local history replay; see withReplays.
+	ReplayPath string `json:"-"`
+}
+
+func loadScores() ([]ScoreEntry, error) {
+	scores, err := loadAllScores()
+	if len(scores) > 50 {
+		scores = scores[:50]
 	}
-	if !bytes.Contains(data, []byte("\"music\"")) {
-		config.Music = true
+	return scores, err
+}
+
+func loadAllScores() ([]ScoreEntry, error) {
+	if err := importLegacyScores(); err != nil {
+		DebugLogf("legacy scores import error: %v", err)
 	}
-	if !bytes.Contains(data, []byte("\"shadow\"")) {
-		config.Shadow = true
+	path, err := scoresPath()
+	if err != nil {
+		return nil, err
 	}
-	if !bytes.Contains(data, []byte("\"animations\"")) {
-		config.Animations = true
+	history, err := loadHistory()
+	if err != nil {
+		return []ScoreEntry{}, err
 	}
-	if !bytes.Contains(data, []byte("\"hard_drop_trace\"")) {
-		config.HardDropTrace = true
+	if err := moveInlineReplays(history); err != nil {
+		DebugLogf("history replay move error: %v", err)
 	}
-	if !bytes.Contains(data, []byte("\"volume\"")) {
-		config.Volume = 70
+	scores := historyScores(history)
+	var legacy []ScoreEntry
+	if _, err := readJSONFile(path, &legacy); os.IsNotExist(err) {
+		return scores, nil
+	} else if err != nil {
+		return scores, err
 	}
-	if config.Theme == "" {
-		config.Theme = themes[0].Name
+	return dedupeScores(legacy, scores), nil
+}
+
+func insertScore(scores []ScoreEntry, entry ScoreEntry) []ScoreEntry {
+	scores = append(scores, entry)
+	sortScores(scores)
+	if len(scores) > 50 {
+		return scores[:50]
 	}
-	if config.Scale < 1 {
-		config.Scale = 1
+	return scores
+}
+
+func mergeScores(local []ScoreEntry, remote []ScoreEntry) []ScoreEntry {
+	merged := dedupeScores(local, remote)
+	if len(merged) > 50 {
+		return merged[:50]
 	}
-	if !config.Shadow {
-		config.Shadow = true
+	return merged
+}
+
+func newScoreID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+// validScoreID reports whether id has the UUID shape newScoreID produces.
+// IDs from imports and servers are checked before they name a file.
+func validScoreID(id string) bool {
+	if len(id) != 36 {
+		return false
 	}
-	if config.Volume < 0 {
-		config.Volume = 0
+	for i, r := range id {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if r != '-' {
+				return false
+			}
+		case !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'):
+			return false
+		}
 	}
-	if config.Volume > 100 {
-		config.Volume = 100
+	return true
+}
+
+func scoreKey(entry ScoreEntry) string {
+	if entry.ID != "" {
+		return entry.ID
 	}
-	return config, nil
+	return legacyScoreKey(entry)
 }
 
-func saveConfig(config Config) error {
-	path, err := configPath()
-	if err != nil {
-		return err
+func legacyScoreKey(entry ScoreEntry) string {
+	return entry.Name + "|" + formatScoreTime(entry.When) + "|" + strconv.Itoa(entry.Score)
+}
+
+// scoreIndex matches entries by ID, falling back to name, minute and score
+// when either side predates IDs.
+type scoreIndex struct {
+	ids        map[string]struct{}
+	legacy     map[string]struct{}
+	legacyNoID map[string]struct{}
+}
+
+func newScoreIndex(entries ...[]ScoreEntry) scoreIndex {
+	index := scoreIndex{
+		ids:        make(map[string]struct{}),
+		legacy:     make(map[string]struct{}),
+		legacyNoID: make(map[string]struct{}),
 	}
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
+	for _, list := range entries {
+		for _, entry := range list {
+			index.add(entry)
+		}
 	}
-	return os.WriteFile(path, data, 0o644)
+	return index
 }
 
-func loadScores() ([]ScoreEntry, error) {
-	path, err := scoresPath()
-	if err != nil {
-		return nil, err
+func (x scoreIndex) add(entry ScoreEntry) {
+	key := legacyScoreKey(entry)
+	x.legacy[key] = struct{}{}
+	if entry.ID == "" {
+		x.legacyNoID[key] = struct{}{}
+		return
 	}
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return []ScoreEntry{}, nil
+	x.ids[entry.ID] = struct{}{}
+}
+
+func (x scoreIndex) has(entry ScoreEntry) bool {
+	key := legacyScoreKey(entry)
+	if entry.ID == "" {
+		_, ok := x.legacy[key]
+		return ok
 	}
-	var scores []ScoreEntry
-	if err := json.Unmarshal(data, &scores); err != nil {
-		return []ScoreEntry{}, err
+	if _, ok := x.ids[entry.ID]; ok {
+		return true
 	}
-	return scores, nil
+	_, ok := x.legacyNoID[key]
+	return ok
 }
 
-func saveScores(scores []ScoreEntry) error {
-	path, err := scoresPath()
-	if err != nil {
-		return err
-	}
-	data, err := json.MarshalIndent(scores, "", "  ")
-	if err != nil {
-		return err
+func dedupeScores(local []ScoreEntry, remote []ScoreEntry) []ScoreEntry {
+	merged := make([]ScoreEntry, 0, len(local)+len(remote))
+	seen := newScoreIndex()
+	for _, entry := range append(append([]ScoreEntry{}, local...), remote...) {
+		if seen.has(entry) {
+			continue
+		}
+		seen.add(entry)
+		merged = append(merged, entry)
 	}
-	return os.WriteFile(path, data, 0o644)
+	sortScores(merged)
+	return merged
 }
 
-func insertScore(scores []ScoreEntry, entry ScoreEntry) []ScoreEntry {
-	scores = append(scores, entry)
-	sort.Slice(scores, func(i, j int) bool {
+func sortScores(scores []ScoreEntry) {
+	sort.SliceStable(scores, func(i, j int) bool {
 		if scores[i].Score == scores[j].Score {
-			return scores[i].When > scores[j].When
+			a, _ := parseScoreTime(scores[i].When)
+			b, _ := parseScoreTime(scores[j].When)
+			return a.After(b)
 		}
 		return scores[i].Score > scores[j].Score
 	})
-	if len(scores) > 50 {
-		return scores[:50]
+}
+
+type scoreRow struct {
+	Entry  ScoreEntry
+	Local  bool
+	Remote bool
+}
+
+func scoreRows(local []ScoreEntry, remote []ScoreEntry, tab int) []scoreRow {
+	localKeys := newScoreIndex(local)
+	remoteKeys := newScoreIndex(remote)
+	var entries []ScoreEntry
+	switch tab {
+	case scoresTabLocal:
+		entries = local
+	case scoresTabGlobal:
+		entries = remote
+	default:
+		entries = dedupeScores(local, remote)
 	}
-	return scores
+	rows := make([]scoreRow, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, scoreRow{Entry: entry, Local: localKeys.has(entry), Remote: remoteKeys.has(entry)})
+	}
+	return rows
 }
 
-func mergeScores(local []ScoreEntry, remote []ScoreEntry) []ScoreEntry {
-	merged := make([]ScoreEntry, 0, len(local)+len(remote))
-	seen := make(map[string]struct{})
-	for _, entry := range append(local, remote...) {
-		key := entry.Name + "|" + entry.When + "|" + strconv.Itoa(entry.Score)
-		if _, ok := seen[key]; ok {
+func filterScores(entries []ScoreEntry, query ScoreQuery, now time.Time) []ScoreEntry {
+	since := query.Since(now)
+	search := strings.ToLower(query.Search)
+	filtered := []ScoreEntry{}
+	for _, entry := range entries {
+		if query.Mode != "" && entryMode(entry) != query.Mode {
 			continue
 		}
-		seen[key] = struct{}{}
-		merged = append(merged, entry)
-	}
-	sort.Slice(merged, func(i, j int) bool {
-		if merged[i].Score == merged[j].Score {
-			return merged[i].When > merged[j].When
+		if search != "" && !strings.Contains(strings.ToLower(entry.Name), search) {
+			continue
 		}
-		return merged[i].Score > merged[j].Score
-	})
-	if len(merged) > 50 {
-		return merged[:50]
+		if !since.IsZero() {
+			played, err := parseScoreTime(entry.When)
+			if err != nil || played.Before(since) {
+				continue
+			}
+		}
+		filtered = append(filtered, entry)
 	}
-	return merged
+	if query.Offset >= len(filtered) {
+		return []ScoreEntry{}
+	}
+	filtered = filtered[query.Offset:]
+	if query.Limit > 0 && len(filtered) > query.Limit {
+		filtered = filtered[:query.Limit]
+	}
+	return filtered
 }
 
-func configPath() (string, error) {
-	root, err := os.UserConfigDir()
-	if err != nil {
-		return "", err
+func entryMode(entry ScoreEntry) string {
+	if entry.Mode == "" {
+		return gameModeMarathon
 	}
-	dir := filepath.Join(root, "tetrui")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return "", err
+	return entry.Mode
+}
+
+func (r scoreRow) Badge() string {
+	switch {
+	case r.Local && r.Remote:
+		return "L+G"
+	case r.Remote:
+		return "G"
+	default:
+		return "L"
 	}
-	return filepath.Join(dir, "config.json"), nil
 }
 
 func scoresPath() (string, error) {
-	root, err := os.UserConfigDir()
+	dir, err := dataDir()
 	if err != nil {
 		return "", err
 	}
-	dir := filepath.Join(root, "tetrui")
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return "", err
-	}
 	return filepath.Join(dir, "scores.json"), nil
 }
 
-func formatAPITime(value string) string {
-	if value == "" {
-		return ""
+func readJSONFile(path string, v any) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parseErr := json.Unmarshal(data, v)
+	if parseErr == nil {
+		return data, nil
+	}
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || json.Unmarshal(backup, v) != nil {
+		return nil, parseErr
+	}
+	DebugLogf("restoring %s from backup: %v", path, parseErr)
+	if err := writeFileAtomic(path, backup); err != nil {
+		DebugLogf("backup restore error: %v", err)
+	}
+	return backup, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	if current, err := os.ReadFile(path); err == nil && json.Valid(current) {
+		if err := replaceFile(path+".bak", current); err != nil {
+			DebugLogf("backup write error: %v", err)
+		}
+	}
+	return replaceFile(path, data)
+}
+
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// lockFile takes an OS advisory lock on path+".lock". The OS drops the lock
+// when its holder exits, so a lock is never stale and is never removed.
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
 	}
-	parsed, err := time.Parse(time.RFC3339, value)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			return func() {
+				unlockFile(file)
+				file.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%s is locked by another tetrui instance", filepath.Base(path))
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+func parseScoreTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.ParseInLocation(scoreTimeLayout, value, time.Local)
+}
+
+func formatScoreTime(value string) string {
+	parsed, err := parseScoreTime(value)
 	if err != nil {
 		return value
 	}
-	return parsed.Local().Format("2006-01-02 15:04")
+	return parsed.Local().Format(scoreTimeLayout)
 }
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFilterScores(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	entries := []ScoreEntry{
+		{Name: "Ann", Score: 500, When: at(time.Hour)},
+		{Name: "bob", Score: 400, When: at(3 * 24 * time.Hour), Mode: gameModeBattle},
+		{Name: "Annie", Score: 300, When: at(30 * 24 * time.Hour)},
+		{Name: "cat", Score: 200, When: "not a time"},
+	}
+	tests := []struct {
+		name  string
+		query ScoreQuery
+		want  []string
+	}{
+		{"all", ScoreQuery{}, []string{"Ann", "bob", "Annie", "cat"}},
+		{"mode", ScoreQuery{Mode: gameModeMarathon}, []string{"Ann", "Annie", "cat"}},
+		{"search ignores case", ScoreQuery{Search: "ann"}, []string{"Ann", "Annie"}},
+		{"last 24h", ScoreQuery{Window: scoreWindowDay}, []string{"Ann"}},
+		{"last 7 days", ScoreQuery{Window: scoreWindowWeek}, []string{"Ann", "bob"}},
+		{"page", ScoreQuery{Offset: 1, Limit: 2}, []string{"bob", "Annie"}},
+		{"past the end", ScoreQuery{Offset: 9}, []string{}},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, entry := range filterScores(entries, test.query, now) {
+			got = append(got, entry.Name)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidScoreID(t *testing.T) {
+	for id, want := range map[string]bool{
+		newScoreID():                           true,
+		"3F2504E0-4F89-41D3-9A0C-0305E82C3301": true,
+		"":                                     false,
+		"../../etc/passwd":                     false,
+		"3f2504e0-4f89-41d3-9a0c-0305e82c330/": false,
+		"3f2504e0x4f89-41d3-9a0c-0305e82c3301": false,
+	} {
+		if got := validScoreID(id); got != want {
+			t.Errorf("validScoreID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestLockFileWaitsForHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	released := make(chan time.Time, 1)
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		released <- time.Now()
+		unlock()
+	}()
+	second, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second()
+	if time.Now().Before(<-released) {
+		t.Fatal("second lock taken while the first was held")
+	}
+}
+
+func TestLockFileIgnoresLeftoverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path+".lock", []byte("12345\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+	if time.Since(start) > time.Second {
+		t.Fatal("a lock file left by a dead process blocked the lock")
+	}
+}
+
+func TestWriteFileAtomicKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scores.json")
+	for _, data := range []string{`["first"]`, `["second"]`} {
+		if err := writeFileAtomic(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != `["second"]` {
+		t.Fatalf("file holds %s", data)
+	}
+	if data, _ := os.ReadFile(path + ".bak"); string(data) != `["first"]` {
+		t.Fatalf("backup holds %s", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("temp files left behind: %v", entries)
+	}
+}
+
+func TestWriteFileAtomicKeepsGoodBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+	if err := writeFileAtomic(path, []byte(`["good"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(`["next"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`["trunc`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte(`["last"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path + ".bak"); string(data) != `["good"]` {
+		t.Fatalf("a corrupt file replaced the backup: %s", data)
+	}
+}
+
+func TestReadJSONFileRestoresBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+	if err := os.WriteFile(path+".bak", []byte(`[{"name":"ann","score":10}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`[{"name":"ann","sc`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var scores []ScoreEntry
+	if _, err := readJSONFile(path, &scores); err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 1 || scores[0].Score != 10 {
+		t.Fatalf("read %+v", scores)
+	}
+	if data, _ := os.ReadFile(path); !json.Valid(data) {
+		t.Fatalf("file was not restored: %s", data)
+	}
+
+	if err := os.WriteFile(path+".bak", []byte(`nope`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`nope`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readJSONFile(path, &scores); err == nil {
+		t.Fatal("read a corrupt file with a corrupt backup")
+	}
+}
+
+func TestConfigRestoredFromBackup(t *testing.T) {
+	useTempDataDir(t)
+	for _, volume := range []int{30, 60} {
+		config := defaultConfig()
+		config.Volume = volume
+		if err := saveConfig(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path, err := configPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"volume": 6`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, _, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Volume != 30 {
+		t.Fatalf("volume %d, want the backup's 30", config.Volume)
+	}
+}
+
+func TestAppendHistoryConcurrent(t *testing.T) {
+	useTempDataDir(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := appendHistory(HistoryRecord{ID: newScoreID(), Name: "ann", Score: i*10 + j}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	records, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 40 {
+		t.Fatalf("%d records, want 40", len(records))
+	}
+}
+
+func TestScoreRowsTabsAndBadges(t *testing.T) {
+	shared := ScoreEntry{ID: newScoreID(), Name: "bob", Score: 500, When: "2024-01-02 10:00"}
+	local := []ScoreEntry{{Name: "ann", Score: 900, When: "2024-01-01 10:00"}, shared}
+	remote := []ScoreEntry{shared, {ID: newScoreID(), Name: "cat", Score: 100, When: "2024-01-03 10:00"}}
+	tests := []struct {
+		tab  int
+		want []string
+	}{
+		{scoresTabLocal, []string{"ann L", "bob L+G"}},
+		{scoresTabGlobal, []string{"bob L+G", "cat G"}},
+		{scoresTabMerged, []string{"ann L", "bob L+G", "cat G"}},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, row := range scoreRows(local, remote, test.tab) {
+			got = append(got, row.Entry.Name+" "+row.Badge())
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s tab: %v, want %v", scoresTabs[test.tab], got, test.want)
+		}
+	}
+}
+
+func TestMergeScoresDedupes(t *testing.T) {
+	id := newScoreID()
+	local := []ScoreEntry{
+		{Name: "ann", Score: 900, When: "2024-01-01 10:00"},
+		{ID: id, Name: "bob", Score: 500, When: "2024-01-02 10:00"},
+	}
+	remote := []ScoreEntry{
+		{ID: newScoreID(), Name: "ann", Score: 900, When: "2024-01-01 10:00"},
+		{ID: id, Name: "bob", Score: 500, When: "2024-01-02 10:00"},
+		{ID: newScoreID(), Name: "bob", Score: 500, When: "2024-01-02 10:00"},
+	}
+	merged := mergeScores(local, remote)
+	if len(merged) != 3 {
+		t.Fatalf("merged %+v", merged)
+	}
+	many := make([]ScoreEntry, 0, 60)
+	for i := range 60 {
+		many = append(many, ScoreEntry{ID: newScoreID(), Name: "p", Score: i})
+	}
+	if merged := mergeScores(many, nil); len(merged) != 50 || merged[0].Score != 59 {
+		t.Fatalf("merged %d scores, top %d", len(merged), merged[0].Score)
+	}
+}
@@ -1,38 +1,80 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+const (
+	scoreWindowAll  = ""
+	scoreWindowDay  = "day"
+	scoreWindowWeek = "week"
+)
+
+type ScoreQuery struct {
+	Limit  int
+	Offset int
+	Mode   string
+	Search string
+	Window string
+}
+
+func (q ScoreQuery) Since(now time.Time) time.Time {
+	switch q.Window {
+	case scoreWindowDay:
+		return now.Add(-24 * time.Hour)
+	case scoreWindowWeek:
+		return now.Add(-7 * 24 * time.Hour)
+	default:
+		return time.Time{}
+	}
+}
+
+func (q ScoreQuery) Values(now time.Time) url.Values {
+	values := url.Values{}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		values.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Mode != "" {
+		values.Set("mode", q.Mode)
+	}
+	if q.Search != "" {
+		values.Set("q", q.Search)
+	}
+	if since := q.Since(now); !since.IsZero() {
+		values.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	return values
+}
+
 type ScoreSync struct {
-	enabled bool
-	baseURL string
-	apiKey  string
-	client  *http.Client
+	enabled   bool
+	backend   ScoreBackend
+	eventsURL string
+	apiKey    string
 }
 
-func NewScoreSyncFromEnv(enabled bool) *ScoreSync {
-	baseURL := strings.TrimSpace(os.Getenv("TETRUI_SCORE_API_URL"))
-	apiKey := strings.TrimSpace(os.Getenv("TETRUI_SCORE_API_KEY"))
-	if baseURL == "" {
-		DebugLogf("score sync disabled: missing TETRUI_SCORE_API_URL")
+func NewScoreSync(config Config) *ScoreSync {
+	backend, err := NewScoreBackend(config)
+	if err != nil {
+		DebugLogf("score sync disabled: %v", err)
 		return nil
 	}
-	DebugLogf("score sync enabled=%v url=%s", enabled, baseURL)
+	DebugLogf("score sync enabled=%v backend=%s", config.Sync, config.ScoreBackend)
 	return &ScoreSync{
-		enabled: enabled,
-		baseURL: strings.TrimRight(baseURL, "/"),
-		apiKey:  apiKey,
-		client: &http.Client{
-			Timeout: 4 * time.Second,
-		},
+		enabled:   config.Sync,
+		backend:   backend,
+		eventsURL: strings.TrimSpace(config.ScoreEvents),
+		apiKey:    strings.TrimSpace(config.ScoreAPIKey),
 	}
 }
 
@@ -47,41 +89,25 @@ func (s *ScoreSync) SetEnabled(enabled bool) {
 	s.enabled = enabled
 }
 
-func (s *ScoreSync) FetchScoresCmd() tea.Cmd {
+func (s *ScoreSync) FetchScoresCmd(query ScoreQuery) tea.Cmd {
 	return func() tea.Msg {
 		if s == nil || !s.enabled {
 			return scoresLoadedMsg{}
 		}
-		DebugLogf("scores fetch start url=%s", s.baseURL)
-		req, err := http.NewRequest(http.MethodGet, s.baseURL, nil)
-		if err != nil {
-			return scoresLoadedMsg{err: err}
-		}
-		if s.apiKey != "" {
-			req.Header.Set("X-Api-Key", s.apiKey)
-		}
-		resp, err := s.client.Do(req)
-		if err != nil {
-			DebugLogf("scores fetch request error: %v", err)
-			return scoresLoadedMsg{err: err}
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode < 200 || resp.StatusCode > 299 {
-			DebugLogf("scores fetch status=%d", resp.StatusCode)
-			return scoresLoadedMsg{err: errUnexpectedStatus(resp.StatusCode)}
-		}
-		var payload []apiScore
-		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-			DebugLogf("scores fetch decode error: %v", err)
-			return scoresLoadedMsg{err: err}
-		}
-		DebugLogf("scores fetch ok count=%d", len(payload))
-		scores := make([]ScoreEntry, 0, len(payload))
-		for _, entry := range payload {
-			scores = append(scores, entry.ToScoreEntry())
-		}
-		return scoresLoadedMsg{scores: scores}
+		scores, err := s.FetchScores(query)
+		return scoresLoadedMsg{query: query, scores: scores, err: err}
+	}
+}
+
+func (s *ScoreSync) FetchScores(query ScoreQuery) ([]ScoreEntry, error) {
+	DebugLogf("scores fetch start query=%+v", query)
+	scores, err := s.backend.Fetch(query)
+	if err != nil {
+		DebugLogf("scores fetch error: %v", err)
+		return nil, err
 	}
+	DebugLogf("scores fetch ok count=%d", len(scores))
+	return scores, nil
 }
 
 func (s *ScoreSync) UploadScoreCmd(entry ScoreEntry) tea.Cmd {
@@ -89,40 +115,24 @@ func (s *ScoreSync) UploadScoreCmd(entry ScoreEntry) tea.Cmd {
 		if s == nil || !s.enabled {
 			return scoreUploadedMsg{}
 		}
-		DebugLogf("score upload start name=%s score=%d", entry.Name, entry.Score)
-		DebugLogf("score upload url=%s", s.baseURL)
-		payload, err := json.Marshal(uploadScore{
-			Name:  entry.Name,
-			Score: entry.Score,
-			Lines: entry.Lines,
-			Level: entry.Level,
-		})
-		if err != nil {
-			return scoreUploadedMsg{err: err}
-		}
-		req, err := http.NewRequest(http.MethodPost, s.baseURL, bytes.NewReader(payload))
-		if err != nil {
-			return scoreUploadedMsg{err: err}
-		}
-		req.Header.Set("Content-Type", "application/json")
-		if s.apiKey != "" {
-			req.Header.Set("X-Api-Key", s.apiKey)
-		}
-		resp, err := s.client.Do(req)
-		if err != nil {
-			DebugLogf("score upload request error: %v", err)
-			return scoreUploadedMsg{err: err}
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode < 200 || resp.StatusCode > 299 {
-			DebugLogf("score upload status=%d", resp.StatusCode)
-			return scoreUploadedMsg{err: errUnexpectedStatus(resp.StatusCode)}
-		}
-		DebugLogf("score upload ok")
-		return scoreUploadedMsg{}
+		return scoreUploadedMsg{entry: entry, err: s.UploadScore(entry)}
 	}
 }
 
+func (s *ScoreSync) UploadScore(entry ScoreEntry) error {
+	DebugLogf("score upload start name=%s score=%d", entry.Name, entry.Score)
+	if err := s.backend.Submit(entry); err != nil {
+		DebugLogf("score upload error: %v", err)
+		return err
+	}
+	DebugLogf("score upload ok")
+	return nil
+}
+
+func (s *ScoreSync) Health() error {
+	return s.backend.Health()
+}
+
 type statusError int
 
 func (s statusError) Error() string {
@@ -133,27 +143,106 @@ func errUnexpectedStatus(code int) error {
 	return statusError(code)
 }
 
+func errorStatus(err error) int {
+	var status statusError
+	if errors.As(err, &status) {
+		return int(status)
+	}
+	return 0
+}
+
+// scoreRejected reports failures that retrying cannot fix, including a bad
+// API key or signature.
+func scoreRejected(err error) bool {
+	switch errorStatus(err) {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden,
+		http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
 type apiScore struct {
-	Name      string `json:"name"`
-	Score     int    `json:"score"`
-	Lines     int    `json:"lines"`
-	Level     int    `json:"level"`
-	CreatedAt string `json:"createdAt"`
+	ID         string `json:"id,omitempty"`
+	Name       string `json:"name"`
+	Score      int    `json:"score"`
+	Lines      int    `json:"lines"`
+	Level      int    `json:"level"`
+	Mode       string `json:"mode,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	Seed       int64  `json:"seed,omitempty"`
+	RuleSet    string `json:"rule_set,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Pieces     int    `json:"pieces,omitempty"`
+	MaxCombo   int    `json:"max_combo,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Board      string `json:"board,omitempty"`
 }
 
 type uploadScore struct {
-	Name  string `json:"name"`
-	Score int    `json:"score"`
-	Lines int    `json:"lines"`
-	Level int    `json:"level"`
+	ID         string `json:"id,omitempty"`
+	PlayedAt   string `json:"played_at,omitempty"`
+	Name       string `json:"name"`
+	Score      int    `json:"score"`
+	Lines      int    `json:"lines"`
+	Level      int    `json:"level"`
+	Mode       string `json:"mode,omitempty"`
+	InputHash  string `json:"input_hash,omitempty"`
+	Replay     string `json:"replay,omitempty"`
+	Seed       int64  `json:"seed,omitempty"`
+	RuleSet    string `json:"rule_set,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Pieces     int    `json:"pieces,omitempty"`
+	MaxCombo   int    `json:"max_combo,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	Board      string `json:"board,omitempty"`
+}
+
+func newUploadScore(entry ScoreEntry) uploadScore {
+	upload := uploadScore{
+		ID:         entry.ID,
+		Name:       entry.Name,
+		Score:      entry.Score,
+		Lines:      entry.Lines,
+		Level:      entry.Level,
+		Mode:       entry.Mode,
+		Seed:       entry.Seed,
+		RuleSet:    entry.RuleSet,
+		Replay:     entry.Replay,
+		DurationMs: entry.DurationMs,
+		Pieces:     entry.Pieces,
+		MaxCombo:   entry.MaxCombo,
+		Version:    entry.Version,
+		Platform:   entry.Platform,
+		Board:      entry.Board,
+	}
+	if played, err := parseScoreTime(entry.When); err == nil {
+		upload.PlayedAt = played.Format(time.RFC3339)
+	}
+	if entry.Replay != "" {
+		upload.InputHash = replayHash(entry.Replay)
+	}
+	return upload
 }
 
 func (s apiScore) ToScoreEntry() ScoreEntry {
 	return ScoreEntry{
-		Name:  s.Name,
-		Score: s.Score,
-		Lines: s.Lines,
-		Level: s.Level,
-		When:  formatAPITime(s.CreatedAt),
+		ID:         s.ID,
+		Name:       s.Name,
+		Score:      s.Score,
+		Lines:      s.Lines,
+		Level:      s.Level,
+		When:       s.CreatedAt,
+		Mode:       s.Mode,
+		Seed:       s.Seed,
+		RuleSet:    s.RuleSet,
+		DurationMs: s.DurationMs,
+		Pieces:     s.Pieces,
+		MaxCombo:   s.MaxCombo,
+		Version:    s.Version,
+		Platform:   s.Platform,
+		Board:      s.Board,
 	}
 }
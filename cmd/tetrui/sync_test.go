@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScoreRejected(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errUnexpectedStatus(http.StatusBadRequest), true},
+		{errUnexpectedStatus(http.StatusUnauthorized), true},
+		{errUnexpectedStatus(http.StatusForbidden), true},
+		{errUnexpectedStatus(http.StatusUnprocessableEntity), true},
+		{errUnexpectedStatus(http.StatusTooManyRequests), false},
+		{errUnexpectedStatus(http.StatusInternalServerError), false},
+		{errors.New("connection refused"), false},
+	}
+	for _, test := range tests {
+		if got := scoreRejected(test.err); got != test.want {
+			t.Errorf("scoreRejected(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestScoreQueryValues(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query ScoreQuery
+		want  string
+	}{
+		{ScoreQuery{}, ""},
+		{ScoreQuery{Limit: 20, Offset: 40}, "limit=20&offset=40"},
+		{ScoreQuery{Mode: gameModeBattle, Search: "ann lee"}, "mode=battle&q=ann+lee"},
+		{ScoreQuery{Window: scoreWindowDay}, "since=2026-03-09T12%3A00%3A00Z"},
+		{ScoreQuery{Window: scoreWindowWeek}, "since=2026-03-03T12%3A00%3A00Z"},
+	}
+	for _, test := range tests {
+		if got := test.query.Values(now).Encode(); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const tbpBoardRows = 40
+
+var tbpNorthOffsets = [7][4]Point{
+	{{-1, 0}, {0, 0}, {1, 0}, {2, 0}},
+	{{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+	{{-1, 0}, {0, 0}, {1, 0}, {0, 1}},
+	{{-1, 0}, {0, 0}, {0, 1}, {1, 1}},
+	{{-1, 1}, {0, 1}, {0, 0}, {1, 0}},
+	{{-1, 0}, {0, 0}, {1, 0}, {-1, 1}},
+	{{-1, 0}, {0, 0}, {1, 0}, {1, 1}},
+}
+
+var tbpOrientations = []string{"north", "east", "south", "west"}
+
+type tbpMessage struct {
+	Type       string          `json:"type"`
+	Name       string          `json:"name,omitempty"`
+	Version    string          `json:"version,omitempty"`
+	Author     string          `json:"author,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	Moves      []tbpMove       `json:"moves,omitempty"`
+	Move       *tbpMove        `json:"move,omitempty"`
+	Piece      string          `json:"piece,omitempty"`
+	Randomizer string          `json:"randomizer,omitempty"`
+	Hold       *string         `json:"hold,omitempty"`
+	Queue      []string        `json:"queue,omitempty"`
+	Combo      *int            `json:"combo,omitempty"`
+	BackToBack *bool           `json:"back_to_back,omitempty"`
+	Board      [][]*string     `json:"board,omitempty"`
+	Features   []string        `json:"features,omitempty"`
+	MoveInfo   json.RawMessage `json:"move_info,omitempty"`
+}
+
+type tbpMove struct {
+	Location tbpLocation `json:"location"`
+	Spin     string      `json:"spin"`
+}
+
+type tbpLocation struct {
+	Type        string `json:"type"`
+	Orientation string `json:"orientation"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+}
+
+type TBPBot struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	name   string
+}
+
+func StartTBPBot(command string) (*TBPBot, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("empty bot command")
+	}
+	cmd := exec.Command(fields[0], fields[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	DebugLogf("tbp bot started cmd=%s pid=%d", command, cmd.Process.Pid)
+	return &TBPBot{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		name:   fields[0],
+	}, nil
+}
+
+func (b *TBPBot) Send(msg tbpMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	DebugLogf("tbp send %s", data)
+	_, err = b.stdin.Write(append(data, '\n'))
+	return err
+}
+
+func (b *TBPBot) Receive() (tbpMessage, error) {
+	for {
+		line, err := b.stdout.ReadString('\n')
+		if err != nil {
+			return tbpMessage{}, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		DebugLogf("tbp recv %s", line)
+		var msg tbpMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return tbpMessage{}, err
+		}
+		return msg, nil
+	}
+}
+
+func (b *TBPBot) Close() {
+	_ = b.Send(tbpMessage{Type: "quit"})
+	_ = b.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		_ = b.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		_ = b.cmd.Process.Kill()
+		<-done
+	}
+}
+
+func tbpStartMessage(g *Game) tbpMessage {
+	board := make([][]*string, tbpBoardRows)
+	for row := range board {
+		board[row] = make([]*string, boardWidth)
+		y := boardHeight - 1 - row
+		if y < 0 {
+			continue
+		}
+		for x := 0; x < boardWidth; x++ {
+			cell := g.Board[y][x]
+			if cell == 0 {
+				continue
+			}
+			name := "G"
+			if cell <= len(pieceNames) {
+				name = pieceNames[cell-1]
+			}
+			board[row][x] = &name
+		}
+	}
+	var hold *string
+	if g.HasHold {
+		name := pieceNames[g.HoldKind]
+		hold = &name
+	}
+	combo := 0
+	if g.Combo > 0 {
+		combo = g.Combo - 1
+	}
+	backToBack := g.BackToBack > 0
+	return tbpMessage{
+		Type:       "start",
+		Hold:       hold,
+		Queue:      []string{pieceNames[g.Current], pieceNames[g.Next]},
+		Combo:      &combo,
+		BackToBack: &backToBack,
+		Board:      board,
+	}
+}
+
+func tbpPieceKind(name string) int {
+	for kind, pieceName := range pieceNames {
+		if pieceName == name {
+			return kind
+		}
+	}
+	return -1
+}
+
+// tbpSpin reads a move's spin field. The engine decides mini or full from the
+// corners itself, so both only mean the last step was a rotation.
+func tbpSpin(spin string) (bool, bool) {
+	switch spin {
+	case "", "none":
+		return false, true
+	case "mini", "full":
+		return true, true
+	}
+	return false, false
+}
+
+func tbpPlacement(loc tbpLocation) (int, Placement, bool) {
+	kind := tbpPieceKind(loc.Type)
+	orientation := -1
+	for i, name := range tbpOrientations {
+		if name == loc.Orientation {
+			orientation = i
+		}
+	}
+	if kind < 0 || orientation < 0 {
+		return 0, Placement{}, false
+	}
+	target := make(map[Point]struct{}, 4)
+	for _, offset := range tbpNorthOffsets[kind] {
+		x, y := offset.X, offset.Y
+		for i := 0; i < orientation; i++ {
+			x, y = y, -x
+		}
+		target[Point{X: loc.X + x, Y: boardHeight - 1 - (loc.Y + y)}] = struct{}{}
+	}
+	targetMin := minPoint(target)
+	for rotation := 0; rotation < 4; rotation++ {
+		shape := make(map[Point]struct{}, 4)
+		for _, p := range pieceRotations[kind][rotation] {
+			shape[p] = struct{}{}
+		}
+		shapeMin := minPoint(shape)
+		dx := targetMin.X - shapeMin.X
+		dy := targetMin.Y - shapeMin.Y
+		matches := true
+		for p := range shape {
+			if _, ok := target[Point{X: p.X + dx, Y: p.Y + dy}]; !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return kind, Placement{X: dx, Y: dy, Rotation: rotation}, true
+		}
+	}
+	return 0, Placement{}, false
+}
+
+func minPoint(points map[Point]struct{}) Point {
+	first := true
+	min := Point{}
+	for p := range points {
+		if first || p.X < min.X {
+			min.X = p.X
+		}
+		if first || p.Y < min.Y {
+			min.Y = p.Y
+		}
+		first = false
+	}
+	return min
+}
+
+type tbpReadyMsg struct {
+	name string
+	err  error
+}
+
+type tbpSuggestionMsg struct {
+	moves []tbpMove
+	err   error
+}
+
+type tbpTickMsg struct{}
+
+type tbpModel struct {
+	bot      *TBPBot
+	game     Game
+	theme    Theme
+	width    int
+	height   int
+	botName  string
+	interval time.Duration
+	pieces   int
+	invalid  int
+	status   string
+	err      error
+}
+
+func runTBP(command string, pps float64) int {
+	bot, err := StartTBPBot(command)
+	if err != nil {
+		fmt.Printf("bot: %v\n", err)
+		return 1
+	}
+	defer bot.Close()
+	config, _, _ := loadConfig()
+	theme := themes[0]
+	if index := themeIndexByName(config.Theme); index >= 0 && themes[index].Name != levelShiftThemeName {
+		theme = themes[index]
+	}
+	interval := 500 * time.Millisecond
+	if pps > 0 {
+		interval = time.Duration(float64(time.Second) / pps)
+	}
+	model := tbpModel{
+		bot:      bot,
+		game:     NewGame(),
+		theme:    theme,
+		botName:  bot.name,
+		interval: interval,
+		status:   "Waiting for bot...",
+	}
+	program := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		DebugLogf("tbp program error: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func (m tbpModel) Init() tea.Cmd {
+	bot := m.bot
+	return func() tea.Msg {
+		info, err := bot.Receive()
+		if err != nil {
+			return tbpReadyMsg{err: err}
+		}
+		if info.Type != "info" {
+			return tbpReadyMsg{err: fmt.Errorf("expected info, got %q", info.Type)}
+		}
+		if err := bot.Send(tbpMessage{Type: "rules", Randomizer: "seven_bag"}); err != nil {
+			return tbpReadyMsg{err: err}
+		}
+		reply, err := bot.Receive()
+		if err != nil {
+			return tbpReadyMsg{err: err}
+		}
+		if reply.Type == "error" {
+			return tbpReadyMsg{err: fmt.Errorf("bot rejected rules: %s", reply.Reason)}
+		}
+		if reply.Type != "ready" {
+			return tbpReadyMsg{err: fmt.Errorf("expected ready, got %q", reply.Type)}
+		}
+		return tbpReadyMsg{name: strings.TrimSpace(info.Name + " " + info.Version)}
+	}
+}
+
+func (m tbpModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case tbpReadyMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.name != "" {
+			m.botName = msg.name
+		}
+		return m, m.startGame()
+	case tbpTickMsg:
+		if m.err != nil || m.game.Over {
+			return m, nil
+		}
+		return m, m.suggestCmd()
+	case tbpSuggestionMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m, m.playSuggestion(msg.moves)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "r":
+			if m.err == nil && m.game.Over {
+				return m, m.startGame()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *tbpModel) startGame() tea.Cmd {
+	m.game = NewGame()
+	m.pieces = 0
+	m.invalid = 0
+	m.status = ""
+	if err := m.bot.Send(tbpStartMessage(&m.game)); err != nil {
+		m.err = err
+		return nil
+	}
+	return m.suggestCmd()
+}
+
+func (m *tbpModel) suggestCmd() tea.Cmd {
+	bot := m.bot
+	return func() tea.Msg {
+		if err := bot.Send(tbpMessage{Type: "suggest"}); err != nil {
+			return tbpSuggestionMsg{err: err}
+		}
+		for {
+			reply, err := bot.Receive()
+			if err != nil {
+				return tbpSuggestionMsg{err: err}
+			}
+			if reply.Type == "suggestion" {
+				return tbpSuggestionMsg{moves: reply.Moves}
+			}
+			if reply.Type == "error" {
+				return tbpSuggestionMsg{err: fmt.Errorf("bot error: %s", reply.Reason)}
+			}
+		}
+	}
+}
+
+func (m *tbpModel) playSuggestion(moves []tbpMove) tea.Cmd {
+	for _, move := range moves {
+		kind, placement, ok := tbpPlacement(move.Location)
+		spin, known := tbpSpin(move.Spin)
+		placement.Spin = spin
+		if !ok || !known || !m.validMove(kind, placement) {
+			m.invalid++
+			DebugLogf("tbp invalid move %+v", move)
+			continue
+		}
+		revealed := []int{}
+		if kind != m.game.Current {
+			hadHold := m.game.HasHold
+			m.game.Hold()
+			if m.game.Over {
+				_ = m.bot.Send(tbpMessage{Type: "stop"})
+				m.status = "Top out. R: restart"
+				return nil
+			}
+			if !hadHold {
+				revealed = append(revealed, m.game.Next)
+			}
+		}
+		if _, placed := m.game.Place(placement.X, placement.Y, placement.Rotation, placement.Spin); !placed {
+			m.err = fmt.Errorf("engine rejected move %+v", move.Location)
+			return nil
+		}
+		m.game.ResolveLineClear()
+		m.pieces++
+		played := move
+		if err := m.bot.Send(tbpMessage{Type: "play", Move: &played}); err != nil {
+			m.err = err
+			return nil
+		}
+		if m.game.Over {
+			_ = m.bot.Send(tbpMessage{Type: "stop"})
+			m.status = "Top out. R: restart"
+			return nil
+		}
+		revealed = append(revealed, m.game.Next)
+		for _, piece := range revealed {
+			if err := m.bot.Send(tbpMessage{Type: "new_piece", Piece: pieceNames[piece]}); err != nil {
+				m.err = err
+				return nil
+			}
+		}
+		return tea.Tick(m.interval, func(time.Time) tea.Msg { return tbpTickMsg{} })
+	}
+	_ = m.bot.Send(tbpMessage{Type: "stop"})
+	m.game.Over = true
+	m.status = "Bot had no valid move. R: restart"
+	return nil
+}
+
+func (m *tbpModel) validMove(kind int, placement Placement) bool {
+	if kind != m.game.Current {
+		if !m.game.CanHold {
+			return false
+		}
+		expected := m.game.Next
+		if m.game.HasHold {
+			expected = m.game.HoldKind
+		}
+		if kind != expected {
+			return false
+		}
+	}
+	sim := m.game.simulate()
+	sim.Current = kind
+	return !sim.collides(placement.X, placement.Y, placement.Rotation) &&
+		sim.collides(placement.X, placement.Y+1, placement.Rotation) &&
+		m.game.reaches(kind, placement)
+}
+
+func (m tbpModel) View() string {
+	if m.err != nil {
+		return center(m.width, m.height, warningStyle(m.theme).Render(fmt.Sprintf("Bot error: %v", m.err))+"\n\n"+helpStyle(m.theme).Render("Q: quit"))
+	}
+	content := renderSpectatorGame(m.game, m.theme)
+	status := fmt.Sprintf("Bot: %s  Pieces: %d  Invalid moves: %d", m.botName, m.pieces, m.invalid)
+	lines := []string{content, "", helpStyle(m.theme).Render(status)}
+	if m.status != "" {
+		lines = append(lines, highlightStyle(m.theme).Render(m.status))
+	}
+	lines = append(lines, helpStyle(m.theme).Render("Q: quit"))
+	return center(m.width, m.height, lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestTBPSpin(t *testing.T) {
+	tests := []struct {
+		spin  string
+		want  bool
+		known bool
+	}{
+		{"", false, true},
+		{"none", false, true},
+		{"mini", true, true},
+		{"full", true, true},
+		{"spin", false, false},
+	}
+	for _, test := range tests {
+		if got, known := tbpSpin(test.spin); got != test.want || known != test.known {
+			t.Errorf("tbpSpin(%q) = %v, %v; want %v, %v", test.spin, got, known, test.want, test.known)
+		}
+	}
+}
+
+func TestTBPValidMoveNeedsReach(t *testing.T) {
+	m := &tbpModel{game: tSpinDoubleGame()}
+	slot := Placement{X: 0, Y: boardHeight - 3, Rotation: 2, Spin: true}
+	if !m.validMove(2, slot) {
+		t.Fatal("rejected a reachable T-spin")
+	}
+	m.game.Board[boardHeight-4][1] = garbageCell
+	m.game.Board[boardHeight-4][0] = garbageCell
+	if m.validMove(2, slot) {
+		t.Fatal("accepted a T-spin into a sealed slot")
+	}
+}